@@ -0,0 +1,90 @@
+package echelon
+
+import (
+	"fmt"
+	"sync"
+)
+
+// deferredEntries keeps the last entries of a scope which are above the level of logger, it's a
+// coroutine safe ring buffer.
+type deferredEntries struct {
+	lock    sync.Mutex
+	entries []*LogEntryMessage
+	// next is the index of the oldest entry once the buffer is full
+	next int
+}
+
+// newDeferredEntries creates a buffer keeping the last capacity entries
+func newDeferredEntries(capacity int) *deferredEntries {
+	return &deferredEntries{
+		entries: make([]*LogEntryMessage, 0, capacity),
+	}
+}
+
+// add keeps entry in buffer, the oldest entry is dropped if the buffer is full
+func (deferred *deferredEntries) add(entry *LogEntryMessage) {
+	deferred.lock.Lock()
+	defer deferred.lock.Unlock()
+	if cap(deferred.entries) == 0 {
+		return
+	}
+	if len(deferred.entries) < cap(deferred.entries) {
+		deferred.entries = append(deferred.entries, entry)
+		return
+	}
+	deferred.entries[deferred.next] = entry
+	deferred.next = (deferred.next + 1) % len(deferred.entries)
+}
+
+// take returns all kept entries from the oldest one and empties the buffer
+func (deferred *deferredEntries) take() []*LogEntryMessage {
+	deferred.lock.Lock()
+	defer deferred.lock.Unlock()
+	result := append(deferred.entries[deferred.next:], deferred.entries[:deferred.next]...)
+	deferred.entries = make([]*LogEntryMessage, 0, cap(deferred.entries))
+	deferred.next = 0
+	return result
+}
+
+// DeferLevel makes the logger keep entries which are above its level but not above 'level', instead
+// of dropping them. The last 'capacity' entries of each scope are kept, and they're sent to renderer
+// right before the scope finishes with failure, so details of failed scopes are shown without
+// running everything in a higher level.
+//
+// Deferred entries are sent in the order they were logged, and they keep the time they were logged,
+// which exporting renderers use to place them among the other entries. They're marked as deferred so
+// that level filters of renderers don't hide them, text renderers show them after the messages of the
+// scope which were shown already.
+//
+// Scopes created later by the logger inherit the setting.
+func (logger *Logger) DeferLevel(level LogLevel, capacity int) {
+	logger.deferredLevel = level
+	logger.deferredCapacity = capacity
+	logger.deferred = newDeferredEntries(capacity)
+}
+
+// deferEntry keeps the message in deferred buffer if its level is deferred
+func (logger *Logger) deferEntry(level LogLevel, format string, args ...interface{}) {
+	if logger.deferred == nil || level > logger.deferredLevel {
+		return
+	}
+	entry := NewLogEntryMessage(logger.scopes, level, "%s", logger.redactor.redact(fmt.Sprintf(format, args...)))
+	entry.deferred = true
+	logger.deferred.add(entry)
+}
+
+// flushDeferred sends all deferred entries to renderer if send is true, else it drops them
+func (logger *Logger) flushDeferred(send bool) {
+	if logger.deferred == nil {
+		return
+	}
+	entries := logger.deferred.take()
+	if !send {
+		return
+	}
+	for _, entry := range entries {
+		logger.entriesChannel <- &LogEvent{
+			LogEntry: entry,
+		}
+	}
+}
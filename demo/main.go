@@ -32,7 +32,8 @@ func generateNode(log *echelon.Logger, magicConstant int) {
 			generateNode(log, magicConstant-1)
 		} else {
 			childJobID := atomic.AddUint64(&jobIDCounter, 1)
-			child := scoped.Bar(fmt.Sprintf("Job %d", childJobID))
+			child := scoped.Bar(fmt.Sprintf("Job %d", childJobID),
+				echelon.WithDecorators(echelon.PercentageDecorator, echelon.ElapsedDecorator))
 			subJobDuration := rand.Intn(magicConstant)
 			for waitSecond := 0; waitSecond < subJobDuration; waitSecond++ {
 				time.Sleep(time.Second)
@@ -0,0 +1,11 @@
+package echelon
+
+import "time"
+
+// MaxWriterLine is the length of pending text of writers which is sent without new line
+const MaxWriterLine = maxWriterLine
+
+// SetRateLimitWithClock sets the rate limit of logger, of which time is returned by now
+func (logger *Logger) SetRateLimitWithClock(limit RateLimit, now func() time.Time) {
+	logger.setRateLimit(limit, now)
+}
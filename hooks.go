@@ -0,0 +1,87 @@
+package echelon
+
+import (
+	"sync"
+)
+
+// Hook inspects an event before it's rendered, and returns the events rendered in its place.
+// Returning nil drops the event, returning the event itself passes it on, and returning several
+// events duplicates it. A hook may modify the event, like adding fields to its message.
+//
+// Hooks are called one by one from the goroutine rendering events, so they should be quick. Nil events
+// returned by hooks are ignored. A hook may log through loggers, the events it logs are passed through
+// hooks and rendered after the events it returns.
+type Hook func(event *LogEvent) []*LogEvent
+
+// hookChain is the list of hooks of a logger tree, it's coroutine safe
+type hookChain struct {
+	lock     sync.RWMutex
+	hooks    []Hook
+	redactor *redactor
+}
+
+// apply passes event through all hooks in order, every event returned by a hook is passed to the
+// next hook. Secrets are masked in messages returned by hooks.
+func (chain *hookChain) apply(event *LogEvent) []*LogEvent {
+	chain.lock.RLock()
+	defer chain.lock.RUnlock()
+	events := []*LogEvent{event}
+	if len(chain.hooks) == 0 {
+		return events
+	}
+	for _, hook := range chain.hooks {
+		var next []*LogEvent
+		for _, current := range events {
+			for _, result := range hook(current) {
+				if result != nil {
+					next = append(next, result)
+				}
+			}
+		}
+		events = next
+	}
+	if chain.redactor.enabled() {
+		for _, current := range events {
+			if current.LogEntry != nil {
+				current.LogEntry.redact(chain.redactor)
+			}
+		}
+	}
+	return events
+}
+
+// applyReceiving applies hooks to event like apply, while events sent to entries meanwhile are received
+// and returned as logged, so that hooks logging through loggers don't block the goroutine rendering events.
+func (chain *hookChain) applyReceiving(event *LogEvent, entries chan *LogEvent) ([]*LogEvent, []*LogEvent) {
+	chain.lock.RLock()
+	hooked := len(chain.hooks) > 0
+	chain.lock.RUnlock()
+	if !hooked {
+		return chain.apply(event), nil
+	}
+	done := make(chan struct{})
+	received := make(chan []*LogEvent)
+	go func() {
+		var logged []*LogEvent
+		for {
+			select {
+			case entry := <-entries:
+				logged = append(logged, entry)
+			case <-done:
+				received <- logged
+				return
+			}
+		}
+	}()
+	events := chain.apply(event)
+	close(done)
+	return events, <-received
+}
+
+// AddHook appends hook to the hooks applied to all events of the logger and all other loggers
+// of the same root, before they're rendered.
+func (logger *Logger) AddHook(hook Hook) {
+	logger.hooks.lock.Lock()
+	defer logger.hooks.lock.Unlock()
+	logger.hooks.hooks = append(logger.hooks.hooks, hook)
+}
@@ -0,0 +1,79 @@
+package echelon_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Logger_Hooks(t *testing.T) {
+	errors := 0
+	var hosts []interface{}
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddSecret("s3cret")
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogEntry != nil && strings.HasPrefix(event.LogEntry.GetMessage(), "waiting") {
+				return nil
+			}
+			return []*echelon.LogEvent{event}
+		})
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogEntry == nil || event.LogEntry.Level != echelon.ErrorLevel {
+				return []*echelon.LogEvent{event}
+			}
+			errors++
+			event.LogEntry.SetField("host", "builder-1")
+			alert := echelon.NewLogEntryMessage(nil, echelon.WarnLevel, "alert: %s s3cret", event.LogEntry.GetMessage())
+			return []*echelon.LogEvent{event, {LogEntry: alert}}
+		})
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogEntry != nil {
+				hosts = append(hosts, event.LogEntry.Fields["host"])
+			}
+			return []*echelon.LogEvent{event}
+		})
+		scoped := logger.Scoped("job")
+		scoped.Infof("waiting for lock...")
+		scoped.Errorf("broken")
+		scoped.Finish(false)
+	})
+	assert.Equal(t, 1, errors)
+	assert.Equal(t, []interface{}{"builder-1", nil}, hosts)
+	assert.Equal(t, []string{"started job", "error broken", "warn alert: broken ***", "failed job"}, lines)
+}
+
+func Test_Logger_Hooks_NilEvents(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			return []*echelon.LogEvent{nil, event, nil}
+		})
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			return []*echelon.LogEvent{event}
+		})
+		scoped := logger.Scoped("job")
+		scoped.Infof("done")
+		scoped.Finish(true)
+	})
+	assert.Equal(t, []string{"started job", "info done", "succeeded job"}, lines)
+}
+
+func Test_Logger_Hooks_Logging(t *testing.T) {
+	logged := make(chan struct{})
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		audit := logger.Scoped("audit")
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogFinished != nil && !event.LogFinished.Success() {
+				audit.Warnf("%s failed", strings.Join(event.LogFinished.GetScopes(), "/"))
+				close(logged)
+			}
+			return []*echelon.LogEvent{event}
+		})
+		scoped := logger.Scoped("job")
+		scoped.Finish(false)
+		<-logged
+		audit.Finish(true)
+	})
+	assert.Equal(t, []string{"started audit", "started job", "failed job", "warn job failed", "succeeded audit"}, lines)
+}
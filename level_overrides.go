@@ -0,0 +1,114 @@
+package echelon
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// LevelEnvironmentVariable is the environment variable of level overrides, see ParseLevelOverrides
+const LevelEnvironmentVariable = "ECHELON_LEVEL"
+
+// ParseLevel returns the log level of name, like "info"
+func ParseLevel(name string) (LogLevel, error) {
+	for level := ErrorLevel; level <= TraceLevel; level++ {
+		if strings.EqualFold(strings.TrimSpace(name), level.String()) {
+			return level, nil
+		}
+	}
+	return ErrorLevel, fmt.Errorf("unknown log level %q", name)
+}
+
+// levelRule sets level of scopes whose paths match pattern
+type levelRule struct {
+	pattern string
+	level   LogLevel
+}
+
+// LevelOverrides are log levels of scopes whose paths match patterns
+type LevelOverrides struct {
+	defaultLevel    LogLevel
+	hasDefaultLevel bool
+	rules           []levelRule
+}
+
+// ParseLevelOverrides parses a comma separated list of level overrides, like
+// "info,build/*=debug,tests/integration=trace".
+//
+// An item without pattern is the level of the root logger. Other items are patterns of scope
+// paths joined by "/" with the syntax of path.Match, and the levels of matched scopes. If several
+// patterns match a scope, the last one wins. Scopes created in a matched scope inherit its level.
+//
+// A pattern matches whole paths, so "build/*" matches the scopes nested in "build" but not "build"
+// itself, use "build=debug" for "build" and all scopes in it.
+func ParseLevelOverrides(spec string) (*LevelOverrides, error) {
+	result := &LevelOverrides{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		separator := strings.LastIndex(item, "=")
+		if separator < 0 {
+			level, err := ParseLevel(item)
+			if err != nil {
+				return nil, err
+			}
+			result.defaultLevel = level
+			result.hasDefaultLevel = true
+			continue
+		}
+		pattern := strings.TrimSpace(item[:separator])
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid scope pattern %q: %w", pattern, err)
+		}
+		level, err := ParseLevel(item[separator+1:])
+		if err != nil {
+			return nil, err
+		}
+		result.rules = append(result.rules, levelRule{pattern: pattern, level: level})
+	}
+	return result, nil
+}
+
+// match returns level of the last rule matching scope with path 'scopes'
+func (overrides *LevelOverrides) match(scopes []string) (LogLevel, bool) {
+	if overrides == nil {
+		return ErrorLevel, false
+	}
+	scopePath := strings.Join(scopes, "/")
+	for i := len(overrides.rules) - 1; i >= 0; i-- {
+		if matched, _ := path.Match(overrides.rules[i].pattern, scopePath); matched {
+			return overrides.rules[i].level, true
+		}
+	}
+	return ErrorLevel, false
+}
+
+// ApplyLevelOverrides sets the level of logger to the default level of overrides if there's
+// one, and applies overrides to scopes created later in logger. Nil overrides do nothing.
+func (logger *Logger) ApplyLevelOverrides(overrides *LevelOverrides) {
+	if overrides == nil {
+		return
+	}
+	if overrides.hasDefaultLevel {
+		logger.SetLevel(overrides.defaultLevel)
+	}
+	logger.overrides = overrides
+}
+
+// ApplyLevelOverridesFromEnvironment parses level overrides from environment variable
+// ECHELON_LEVEL and applies them to logger, nothing is done if the variable is empty.
+func (logger *Logger) ApplyLevelOverridesFromEnvironment() error {
+	spec := os.Getenv(LevelEnvironmentVariable)
+	if spec == "" {
+		return nil
+	}
+	overrides, err := ParseLevelOverrides(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", LevelEnvironmentVariable, err)
+	}
+	logger.ApplyLevelOverrides(overrides)
+	return nil
+}
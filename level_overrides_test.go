@@ -0,0 +1,69 @@
+package echelon_test
+
+import (
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseLevelOverrides(t *testing.T) {
+	overrides, err := echelon.ParseLevelOverrides("warn, build/*=debug,tests/integration=TRACE")
+	assert.NoError(t, err)
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.ApplyLevelOverrides(overrides)
+		logger.Infof("root info")
+		build := logger.Scoped("build")
+		build.Debugf("build debug")
+		compile := build.Scoped("compile")
+		compile.Debugf("compile debug")
+		compile.Scoped("step").Debugf("step debug")
+		logger.Scoped("tests").Scoped("integration").Tracef("integration trace")
+	})
+	assert.Equal(t, []string{
+		"started build", "started build/compile", "debug compile debug",
+		"started build/compile/step", "debug step debug",
+		"started tests", "started tests/integration", "trace integration trace",
+	}, lines)
+}
+
+func Test_ParseLevelOverrides_WholeScope(t *testing.T) {
+	overrides, err := echelon.ParseLevelOverrides("build=debug")
+	assert.NoError(t, err)
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.ApplyLevelOverrides(overrides)
+		build := logger.Scoped("build")
+		build.Debugf("build debug")
+		build.Scoped("compile").Debugf("compile debug")
+	})
+	assert.Equal(t, []string{
+		"started build", "debug build debug", "started build/compile", "debug compile debug",
+	}, lines)
+}
+
+func Test_Logger_ApplyLevelOverrides_Nil(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.ApplyLevelOverrides(nil)
+		logger.Scoped("build").Debugf("hidden")
+	})
+	assert.Equal(t, []string{"started build"}, lines)
+}
+
+func Test_ParseLevelOverrides_Invalid(t *testing.T) {
+	_, err := echelon.ParseLevelOverrides("info,build=verbose")
+	assert.Error(t, err)
+	_, err = echelon.ParseLevelOverrides("build/[=debug")
+	assert.Error(t, err)
+}
+
+func Test_Logger_SetLevel_Subtree(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		quiet := logger.Scoped("quiet")
+		verbose := logger.Scoped("verbose")
+		child := verbose.Scoped("child")
+		verbose.SetLevel(echelon.DebugLevel)
+		quiet.Debugf("hidden")
+		child.Debugf("shown")
+	})
+	assert.Equal(t, []string{"started quiet", "started verbose", "started verbose/child", "debug shown"}, lines)
+}
@@ -0,0 +1,32 @@
+// +build !windows
+
+package echelon
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// CycleLevelOnSignals cycles the level of logger while the process is running: SIGUSR1 makes it
+// one step more verbose and SIGUSR2 makes it one step less verbose, see CycleLevel. It returns a
+// function which stops handling the signals.
+func (logger *Logger) CycleLevelOnSignals() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for {
+			select {
+			case received := <-signals:
+				logger.CycleLevel(received == syscall.SIGUSR1)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,7 @@
+package echelon
+
+// CycleLevelOnSignals does nothing on Windows since there are no SIGUSR1 and SIGUSR2, use
+// CycleLevel instead. It returns a function which does nothing.
+func (logger *Logger) CycleLevelOnSignals() (stop func()) {
+	return func() {}
+}
@@ -26,6 +26,23 @@ const (
 	DefaultProgress = 100
 )
 
+// String returns name of level, like "info"
+func (level LogLevel) String() string {
+	switch level {
+	case ErrorLevel:
+		return "error"
+	case WarnLevel:
+		return "warn"
+	case InfoLevel:
+		return "info"
+	case DebugLevel:
+		return "debug"
+	case TraceLevel:
+		return "trace"
+	}
+	return fmt.Sprintf("level(%d)", uint32(level))
+}
+
 // LogScopeStarted sends start message to with time stamp to node specified by scopes
 type LogScopeStarted struct {
 	// scopes
@@ -34,6 +51,8 @@ type LogScopeStarted struct {
 	total int64
 	// time is the time of start.
 	time   time.Time
+	// options are the optional settings of scope
+	options ScopeOptions
 }
 
 // NewLogScopeStarted will create a LogScopeStarted with LogScopeStarted. scopes is path of log.
@@ -46,6 +65,14 @@ func NewLogScopeStarted(total int64,scopes ...string) *LogScopeStarted {
 	}
 }
 
+// NewLogScopeStartedWithOptions will create a LogScopeStarted like NewLogScopeStarted, with
+// optional settings of the scope.
+func NewLogScopeStartedWithOptions(total int64, options ScopeOptions, scopes ...string) *LogScopeStarted {
+	result := NewLogScopeStarted(total, scopes...)
+	result.options = options
+	return result
+}
+
 // GetScopes will return scopes path of entry
 func (entry *LogScopeStarted) GetScopes() []string {
 	return entry.scopes
@@ -56,10 +83,20 @@ func (entry *LogScopeStarted) GetProgressSize() int64 {
 	return entry.total
 }
 
+// GetOptions returns the optional settings of scope
+func (entry *LogScopeStarted) GetOptions() ScopeOptions {
+	return entry.options
+}
+
 // LogScopeFinished sends finished message and finish status(succeed or failed) to node specified with path 
 type LogScopeFinished struct {
 	scopes  []string
 	success bool
+	// cause is the error which made the scope fail, it's nil if the scope succeeded or the cause is unknown
+	cause error
+	// skipped tells the scope was skipped instead of run, and reason tells why. A skipped scope is successful.
+	skipped bool
+	reason  string
 }
 
 // NewLogScopeFinished will create LogScopeFinished
@@ -70,6 +107,39 @@ func NewLogScopeFinished(success bool, scopes ...string) *LogScopeFinished {
 	}
 }
 
+// NewLogScopeFailed will create LogScopeFinished of a scope failed because of cause
+func NewLogScopeFailed(cause error, scopes ...string) *LogScopeFinished {
+	return &LogScopeFinished{
+		scopes: scopes,
+		cause:  cause,
+	}
+}
+
+// NewLogScopeSkipped will create LogScopeFinished of a scope skipped because of reason
+func NewLogScopeSkipped(reason string, scopes ...string) *LogScopeFinished {
+	return &LogScopeFinished{
+		scopes:  scopes,
+		success: true,
+		skipped: true,
+		reason:  reason,
+	}
+}
+
+// Cause returns the error which made the scope fail, it's nil if the scope succeeded or the cause is unknown
+func (entry *LogScopeFinished) Cause() error {
+	return entry.cause
+}
+
+// Skipped returns whether the scope was skipped instead of run, a skipped scope is successful as well
+func (entry *LogScopeFinished) Skipped() bool {
+	return entry.skipped
+}
+
+// SkipReason returns why the scope was skipped, it's empty if the scope wasn't skipped
+func (entry *LogScopeFinished) SkipReason() string {
+	return entry.reason
+}
+
 // Success returns wheter to LogScopeFinished has finished successfully
 func (entry *LogScopeFinished) Success() bool {
 	return entry.success
@@ -89,6 +159,13 @@ type LogEntryMessage struct {
 	arguments []interface{}
 	// scopes are the names of scopes, which points out the path of log.
 	scopes    []string
+	// Fields are structured data of message, like the host name. Text renderers show the message
+	// only, while exporting renderers keep the fields.
+	Fields map[string]interface{}
+	// time is the time the message was logged
+	time time.Time
+	// deferred tells the message was kept above the level of logger and sent once its scope failed
+	deferred bool
 }
 
 // NewLogEntryMessage creates a new log entry with path 'scopes', log level 'level', and message format, a...
@@ -98,9 +175,22 @@ func NewLogEntryMessage(scopes []string, level LogLevel, format string, a ...int
 		format:    format,
 		arguments: a,
 		scopes:    scopes,
+		time:      time.Now(),
 	}
 }
 
+// GetTime returns the time the message was logged, which is earlier than the time it's rendered for
+// deferred messages
+func (entry *LogEntryMessage) GetTime() time.Time {
+	return entry.time
+}
+
+// Deferred returns whether the message was kept above the level of logger and sent once its scope
+// failed, see (*Logger).DeferLevel. Renderers show deferred messages regardless of their level filters.
+func (entry *LogEntryMessage) Deferred() bool {
+	return entry.deferred
+}
+
 // GetMessage returns messages of log
 func (entry *LogEntryMessage) GetMessage() string {
 	return fmt.Sprintf(entry.format, entry.arguments...)
@@ -111,6 +201,30 @@ func (entry *LogEntryMessage) GetScopes() []string {
 	return entry.scopes
 }
 
+// SetMessage replaces message of log with text
+func (entry *LogEntryMessage) SetMessage(text string) {
+	entry.format = "%s"
+	entry.arguments = []interface{}{text}
+}
+
+// SetField sets field key of message to value
+func (entry *LogEntryMessage) SetField(key string, value interface{}) {
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]interface{})
+	}
+	entry.Fields[key] = value
+}
+
+// redact masks secrets in message and string fields of log
+func (entry *LogEntryMessage) redact(r *redactor) {
+	entry.SetMessage(r.redact(entry.GetMessage()))
+	for key, value := range entry.Fields {
+		if text, ok := value.(string); ok {
+			entry.Fields[key] = r.redact(text)
+		}
+	}
+}
+
 // LogProcessMessage sends progress message to node specified by scopes
 type LogProcessMessage struct {
 	Progress int64
@@ -1,7 +1,16 @@
 package echelon
 
-// genericLogEntry is a log entry contains whether started log, finished log or running log
-type genericLogEntry struct {
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// inheritedLevel is the level of logger which uses level of its parent
+const inheritedLevel = math.MaxUint32
+
+// LogEvent is a log entry contains whether started log, finished log or running log
+type LogEvent struct {
 	LogStarted  *LogScopeStarted
 	LogFinished *LogScopeFinished
 	LogEntry    *LogEntryMessage
@@ -23,77 +32,115 @@ type LogRenderer interface {
 // Logger is a log object with a log level, scopes and entries chan.entries Channel
 // will render all entries it receive after calling (*Logger).streamEntries function
 type Logger struct {
-	level  LogLevel
-	scopes []string
+	// level is the log level of logger, it's inheritedLevel if the level of parent is used.
+	// It's accessed atomically.
+	level  uint32
+	parent *Logger
+	// overrides are levels of scopes created later, matched by patterns of their paths
+	overrides *LevelOverrides
+	scopes    []string
 	// entriesChannel will render all entries it receive after calling (*Logger).streamEntries function
-	entriesChannel chan *genericLogEntry
+	entriesChannel chan *LogEvent
+	// deferred keeps entries above level up to deferredLevel, it's nil if no level is deferred
+	deferred         *deferredEntries
+	deferredLevel    LogLevel
+	deferredCapacity int
+	// redactor masks secrets, it's shared by all loggers of the same root
+	redactor *redactor
+	// hooks are applied to all events before rendering, they're shared by all loggers of the same root
+	hooks *hookChain
+	// limiter limits messages of logger by rateLimit, it's nil if there's no limitation
+	rateLimit *RateLimit
+	limiter   *rateLimiter
 }
 
 // NewLogger creates a log object with new generated entries channel. And use renderer as renderer of logger
 func NewLogger(level LogLevel, renderer LogRenderer) *Logger {
 	logger := &Logger{
-		level:          level,
-		entriesChannel: make(chan *genericLogEntry),
+		level:          uint32(level),
+		entriesChannel: make(chan *LogEvent),
+		redactor:       &redactor{},
 	}
+	logger.hooks = &hookChain{redactor: logger.redactor}
 	go logger.streamEntries(renderer)
 	return logger
 }
 
 // Scoped creates a node with name(scope)
-func (logger *Logger) Scoped(scope string) *Logger {
-	result := &Logger{
-		level:          logger.level,
-		scopes:         append(logger.scopes, scope),
-		entriesChannel: logger.entriesChannel,
-	}
-	result.entriesChannel <- &genericLogEntry{
-		LogStarted: NewLogScopeStarted(NoProgress, result.scopes...),
-	}
-	return result
+func (logger *Logger) Scoped(scope string, options ...ScopeOption) *Logger {
+	return logger.startChild(NoProgress, scope, options)
 }
 
 // Bar creates a node with progress bar and name (scope)
-func (logger *Logger) Bar(scope string) *Logger {
-	result := &Logger{
-		level:          logger.level,
-		scopes:         append(logger.scopes, scope),
-		entriesChannel: logger.entriesChannel,
-	}
-	result.entriesChannel <- &genericLogEntry{
-		LogStarted: NewLogScopeStarted(DefaultProgress, result.scopes...),
-	}
-	return result
+func (logger *Logger) Bar(scope string, options ...ScopeOption) *Logger {
+	return logger.startChild(DefaultProgress, scope, options)
 }
 
 // BarWithSize creates a node with progress bar which has a certain progress size and name (scope)
-func (logger *Logger) BarWithSize(total int64, scope string) *Logger {
+func (logger *Logger) BarWithSize(total int64, scope string, options ...ScopeOption) *Logger {
+	return logger.startChild(total, scope, options)
+}
+
+// startChild creates a child logger with name (scope) and sends its start message
+func (logger *Logger) startChild(total int64, scope string, options []ScopeOption) *Logger {
+	// copy scopes so that siblings never share the same underlying array
+	scopes := make([]string, len(logger.scopes), len(logger.scopes)+1)
+	copy(scopes, logger.scopes)
 	result := &Logger{
-		level:          logger.level,
-		scopes:         append(logger.scopes, scope),
-		entriesChannel: logger.entriesChannel,
+		level:            inheritedLevel,
+		parent:           logger,
+		overrides:        logger.overrides,
+		scopes:           append(scopes, logger.redactor.redact(scope)),
+		entriesChannel:   logger.entriesChannel,
+		redactor:         logger.redactor,
+		hooks:            logger.hooks,
+		deferredLevel:    logger.deferredLevel,
+		deferredCapacity: logger.deferredCapacity,
+	}
+	if logger.deferred != nil {
+		result.deferred = newDeferredEntries(logger.deferredCapacity)
+	}
+	if logger.rateLimit != nil {
+		result.rateLimit = logger.rateLimit
+		result.limiter = newRateLimiter(*logger.rateLimit, logger.limiter.now, result.reportSuppressed)
 	}
-	result.entriesChannel <- &genericLogEntry{
-		LogStarted: NewLogScopeStarted(total, result.scopes...),
+	if level, ok := logger.overrides.match(result.scopes); ok {
+		result.SetLevel(level)
+	}
+	result.entriesChannel <- &LogEvent{
+		LogStarted: NewLogScopeStartedWithOptions(total, NewScopeOptions(options...), result.scopes...),
 	}
 	return result
 }
 
-// streamEntries will continiously render all entry receinved from logger entries channel.
+// streamEntries will continiously render all entry receinved from logger entries channel, after
+// passing them through hooks of logger.
 func (logger *Logger) streamEntries(renderer LogRenderer) {
+	// pending are the entries sent while hooks were applied, they're rendered before new entries
+	var pending []*LogEvent
 	for {
-		// receive entry from logger.entriesChannel
-		entry := <-logger.entriesChannel
-		if entry.LogStarted != nil {
-			renderer.RenderScopeStarted(entry.LogStarted)
-		}
-		if entry.LogFinished != nil {
-			renderer.RenderScopeFinished(entry.LogFinished)
+		var event *LogEvent
+		if len(pending) > 0 {
+			event, pending = pending[0], pending[1:]
+		} else {
+			// receive entry from logger.entriesChannel
+			event = <-logger.entriesChannel
 		}
-		if entry.LogEntry != nil {
-			renderer.RenderMessage(entry.LogEntry)
-		}
-		if entry.LogProcess != nil {
-			renderer.RenderProcess(entry.LogProcess)
+		events, logged := logger.hooks.applyReceiving(event, logger.entriesChannel)
+		pending = append(pending, logged...)
+		for _, entry := range events {
+			if entry.LogStarted != nil {
+				renderer.RenderScopeStarted(entry.LogStarted)
+			}
+			if entry.LogFinished != nil {
+				renderer.RenderScopeFinished(entry.LogFinished)
+			}
+			if entry.LogEntry != nil {
+				renderer.RenderMessage(entry.LogEntry)
+			}
+			if entry.LogProcess != nil {
+				renderer.RenderProcess(entry.LogProcess)
+			}
 		}
 	}
 }
@@ -126,30 +173,113 @@ func (logger *Logger) Errorf(format string, args ...interface{}) {
 // Logf sends a log message with LogLevel level to logger
 func (logger *Logger) Logf(level LogLevel, format string, args ...interface{}) {
 	if logger.IsLogLevelEnabled(level) {
-		logger.entriesChannel <- &genericLogEntry{
-			LogEntry: NewLogEntryMessage(logger.scopes, level, format, args...),
+		if logger.rateLimited() {
+			return
+		}
+		logger.entriesChannel <- &LogEvent{
+			LogEntry: logger.newEntryMessage(level, format, args...),
 		}
+	} else {
+		logger.deferEntry(level, format, args...)
+	}
+}
+
+// newEntryMessage creates message of logger, secrets in the message are masked
+func (logger *Logger) newEntryMessage(level LogLevel, format string, args ...interface{}) *LogEntryMessage {
+	if !logger.redactor.enabled() {
+		return NewLogEntryMessage(logger.scopes, level, format, args...)
 	}
+	return NewLogEntryMessage(logger.scopes, level, "%s", logger.redactor.redact(fmt.Sprintf(format, args...)))
 }
 
 // Finish will finsh a log with success status (true for succeed, false for failed),
-// it will sends a NewLogScopeFinished to logger
+// it will sends a NewLogScopeFinished to logger. Deferred entries are sent before it
+// if the log failed.
 func (logger *Logger) Finish(success bool) {
-	logger.entriesChannel <- &genericLogEntry{
-		LogFinished: NewLogScopeFinished(success, logger.scopes...),
+	logger.finish(NewLogScopeFinished(success, logger.scopes...))
+}
+
+// FinishWithError finishes logger, it succeeds if err is nil, otherwise it fails and err is its cause
+// shown by renderers. Secrets are masked in the message of err.
+func (logger *Logger) FinishWithError(err error) {
+	if err == nil {
+		logger.Finish(true)
+		return
+	}
+	logger.finish(NewLogScopeFailed(logger.redactor.redactError(err), logger.scopes...))
+}
+
+// Skip finishes logger as skipped instead of run, reason tells why. Skipped scopes are successful.
+func (logger *Logger) Skip(reason string) {
+	logger.finish(NewLogScopeSkipped(logger.redactor.redact(reason), logger.scopes...))
+}
+
+// finish flushes pending messages of logger and sends entry which finishes it
+func (logger *Logger) finish(entry *LogScopeFinished) {
+	logger.flushDeferred(!entry.Success())
+	if logger.limiter != nil {
+		logger.reportSuppressed(logger.limiter.stop())
+	}
+	logger.entriesChannel <- &LogEvent{
+		LogFinished: entry,
 	}
 }
 
 // IsLogLevelEnabled returns wheter a log will print to Writer
 func (logger *Logger) IsLogLevelEnabled(level LogLevel) bool {
-	return level <= logger.level
+	return level <= logger.GetLevel()
+}
+
+// SetLevel sets log level of logger, it affects the logger and all its scopes which
+// don't have their own levels, including the ones created before. It's a coroutine
+// safe function.
+func (logger *Logger) SetLevel(level LogLevel) {
+	atomic.StoreUint32(&logger.level, uint32(level))
+}
+
+// GetLevel returns log level of logger, which is the level of the nearest logger
+// with its own level from logger to the root. It's a coroutine safe function.
+func (logger *Logger) GetLevel() LogLevel {
+	for current := logger; current != nil; current = current.parent {
+		if level := atomic.LoadUint32(&current.level); level != inheritedLevel {
+			return LogLevel(level)
+		}
+	}
+	return InfoLevel
+}
+
+// CycleLevel makes the level of logger one step more verbose if verbose is true, else one step
+// less verbose, it stays at TraceLevel and ErrorLevel once it reaches them. A notice about the new
+// level is sent to renderer regardless of the level. It returns the new level, and it's a coroutine
+// safe function.
+func (logger *Logger) CycleLevel(verbose bool) LogLevel {
+	for {
+		stored := atomic.LoadUint32(&logger.level)
+		current := logger.GetLevel()
+		next := current
+		if verbose && current < TraceLevel {
+			next = current + 1
+		} else if !verbose && current > ErrorLevel {
+			next = current - 1
+		}
+		if atomic.CompareAndSwapUint32(&logger.level, stored, uint32(next)) {
+			notice := "log level changed to %s"
+			if next == current {
+				notice = "log level is already %s"
+			}
+			logger.entriesChannel <- &LogEvent{
+				LogEntry: NewLogEntryMessage(logger.scopes, WarnLevel, notice, next),
+			}
+			return next
+		}
+	}
 }
 
 // SetProgress will sets progress of logger
 func (logger *Logger) SetProgress(progress int64) {
 	pm := NewLogProcessMessage(logger.scopes...)
 	pm.Progress = progress
-	logger.entriesChannel <- &genericLogEntry{
+	logger.entriesChannel <- &LogEvent{
 		LogProcess: pm,
 	}
 }
@@ -158,7 +288,7 @@ func (logger *Logger) SetProgress(progress int64) {
 func (logger *Logger) AddProgress(addprogress int64) {
 	pm := NewLogProcessMessage(logger.scopes...)
 	pm.Addprogress = addprogress
-	logger.entriesChannel <- &genericLogEntry{
+	logger.entriesChannel <- &LogEvent{
 		LogProcess: pm,
 	}
 }
@@ -167,7 +297,7 @@ func (logger *Logger) AddProgress(addprogress int64) {
 func (logger *Logger) SetPercentage(percentage int) {
 	pm := NewLogProcessMessage(logger.scopes...)
 	pm.Percentage = percentage
-	logger.entriesChannel <- &genericLogEntry{
+	logger.entriesChannel <- &LogEvent{
 		LogProcess: pm,
 	}
 }
@@ -176,7 +306,7 @@ func (logger *Logger) SetPercentage(percentage int) {
 func (logger *Logger) AddPercentage(addpercentage int) {
 	pm := NewLogProcessMessage(logger.scopes...)
 	pm.Addpercentage = addpercentage
-	logger.entriesChannel <- &genericLogEntry{
+	logger.entriesChannel <- &LogEvent{
 		LogProcess: pm,
 	}
 }
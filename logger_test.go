@@ -0,0 +1,164 @@
+package echelon_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingRenderer records all events as lines, done is closed once the root finishes
+type recordingRenderer struct {
+	lines []string
+	done  chan struct{}
+}
+
+func newRecordingRenderer() *recordingRenderer {
+	return &recordingRenderer{done: make(chan struct{})}
+}
+
+func (r *recordingRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	r.lines = append(r.lines, "started "+strings.Join(entry.GetScopes(), "/"))
+}
+
+func (r *recordingRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	if len(entry.GetScopes()) == 0 {
+		close(r.done)
+		return
+	}
+	outcome := "failed "
+	if entry.Skipped() {
+		outcome = "skipped "
+	} else if entry.Success() {
+		outcome = "succeeded "
+	}
+	line := outcome + strings.Join(entry.GetScopes(), "/")
+	if entry.Cause() != nil {
+		line += ": " + entry.Cause().Error()
+	}
+	r.lines = append(r.lines, line)
+}
+
+func (r *recordingRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	line := entry.Level.String() + " " + entry.GetMessage()
+	if entry.Deferred() {
+		line = "deferred " + line
+	}
+	r.lines = append(r.lines, line)
+}
+
+func (r *recordingRenderer) RenderProcess(entry *echelon.LogProcessMessage) {
+	line := "progress " + strings.Join(entry.GetScopes(), "/")
+	if entry.Addprogress != 0 {
+		line += fmt.Sprintf(" +%d", entry.Addprogress)
+	}
+	r.lines = append(r.lines, line)
+}
+
+// run runs f with a logger of level and returns the recorded lines
+func run(level echelon.LogLevel, f func(logger *echelon.Logger)) []string {
+	renderer := newRecordingRenderer()
+	logger := echelon.NewLogger(level, renderer)
+	f(logger)
+	logger.Finish(true)
+	<-renderer.done
+	return renderer.lines
+}
+
+func Test_Logger_DeferLevel(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.DeferLevel(echelon.DebugLevel, 2)
+		ok := logger.Scoped("ok")
+		ok.Debugf("dropped on success")
+		ok.Finish(true)
+		failed := logger.Scoped("failed")
+		failed.Debugf("dropped by capacity")
+		failed.Infof("visible")
+		failed.Debugf("first %d", 1)
+		failed.Tracef("dropped by level")
+		failed.Debugf("second")
+		failed.Finish(false)
+	})
+	assert.Equal(t, []string{
+		"started ok", "succeeded ok",
+		"started failed", "info visible", "deferred debug first 1", "deferred debug second", "failed failed",
+	}, lines)
+}
+
+func Test_Logger_DeferLevel_KeepsTimes(t *testing.T) {
+	var entries []*echelon.LogEntryMessage
+	run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogEntry != nil {
+				entries = append(entries, event.LogEntry)
+			}
+			return []*echelon.LogEvent{event}
+		})
+		logger.DeferLevel(echelon.DebugLevel, 2)
+		failed := logger.Scoped("failed")
+		failed.Debugf("deferred")
+		time.Sleep(time.Millisecond)
+		failed.Infof("shown")
+		failed.Finish(false)
+	})
+	assert.Len(t, entries, 2)
+	shown, deferred := entries[0], entries[1]
+	assert.False(t, shown.Deferred())
+	assert.True(t, deferred.Deferred())
+	assert.True(t, deferred.GetTime().Before(shown.GetTime()))
+}
+
+func Test_Logger_CycleLevel(t *testing.T) {
+	lines := run(echelon.WarnLevel, func(logger *echelon.Logger) {
+		scoped := logger.Scoped("job")
+		scoped.Infof("hidden")
+		assert.Equal(t, echelon.InfoLevel, logger.CycleLevel(true))
+		scoped.Infof("shown")
+		assert.Equal(t, echelon.WarnLevel, logger.CycleLevel(false))
+		assert.Equal(t, echelon.ErrorLevel, logger.CycleLevel(false))
+		assert.Equal(t, echelon.ErrorLevel, logger.CycleLevel(false))
+		logger.SetLevel(echelon.TraceLevel)
+		assert.Equal(t, echelon.TraceLevel, logger.CycleLevel(true))
+	})
+	assert.Equal(t, []string{
+		"started job", "warn log level changed to info", "info shown", "warn log level changed to warn",
+		"warn log level changed to error", "warn log level is already error", "warn log level is already trace",
+	}, lines)
+}
+
+func Test_Logger_FinishWithError(t *testing.T) {
+	cause := errors.New("exit status 1 with token s3cret")
+	var rendered error
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddSecret("s3cret")
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogFinished != nil && event.LogFinished.Cause() != nil {
+				rendered = event.LogFinished.Cause()
+			}
+			return []*echelon.LogEvent{event}
+		})
+		logger.Scoped("ok").FinishWithError(nil)
+		logger.Scoped("broken").FinishWithError(cause)
+		logger.Scoped("optional").Skip("not needed")
+	})
+	assert.Equal(t, []string{
+		"started ok", "succeeded ok",
+		"started broken", "failed broken: exit status 1 with token ***",
+		"started optional", "skipped optional",
+	}, lines)
+	assert.True(t, errors.Is(rendered, cause))
+}
+
+func Test_Logger_SiblingScopes(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		deep := logger.Scoped("a").Scoped("b").Scoped("c")
+		first := deep.Scoped("first")
+		deep.Scoped("second")
+		first.Finish(true)
+	})
+	assert.Contains(t, lines, "succeeded a/b/c/first")
+}
@@ -0,0 +1,137 @@
+package echelon
+
+// BarDecorator is a piece of information displayed next to a progress bar
+type BarDecorator int
+
+const (
+	// PercentageDecorator shows the progress in percent, like "42%"
+	PercentageDecorator BarDecorator = iota
+	// CountersDecorator shows the current progress and the total, like "12/40"
+	CountersDecorator
+	// BytesDecorator shows the current progress and the total in IEC units, like "1.2 MiB/4.0 MiB"
+	BytesDecorator
+	// SIBytesDecorator shows the current progress and the total in SI units, like "1.2 MB/4.0 MB"
+	SIBytesDecorator
+	// RateDecorator shows the moving average of progress per second, like "12/s"
+	RateDecorator
+	// BytesRateDecorator shows the moving average of progress per second in IEC units, like "1.2 MiB/s"
+	BytesRateDecorator
+	// SIBytesRateDecorator shows the moving average of progress per second in SI units, like "1.2 MB/s"
+	SIBytesRateDecorator
+	// ETADecorator shows the estimated remaining time computed from the moving average rate
+	ETADecorator
+	// ElapsedDecorator shows the time passed since the start of the bar
+	ElapsedDecorator
+)
+
+// AggregateMode tells how a scope derives its progress from its children
+type AggregateMode int
+
+const (
+	// NoAggregate means the progress of scope is not derived from its children
+	NoAggregate AggregateMode = iota
+	// AggregateByCount makes the progress of scope the number of completed children out of all children
+	AggregateByCount
+	// AggregateByWeight makes the progress of scope the weighted average of progress of its children.
+	// A child weighs its explicit weight if it has one, else the total of its progress bar if it has one,
+	// else 1.
+	AggregateByWeight
+)
+
+// ScopeOptions contains the optional settings of a scope, renderers decide how to apply them
+type ScopeOptions struct {
+	// Decorators are displayed next to the progress bar of the scope. nil means the
+	// decorators configured in renderer are used.
+	Decorators []BarDecorator
+	// BarStyle is the five runes style of progress bar, see WithBarStyle. Empty means the
+	// style configured in renderer is used.
+	BarStyle string
+	// BarColors are the colors of progress bar. nil means the colors configured in renderer
+	// are used.
+	BarColors *BarColors
+	// SubCellPrecision tells whether the progress bar draws fractions of a cell. nil means
+	// the setting of renderer is used.
+	SubCellPrecision *bool
+	// Aggregate tells how the scope displays a progress bar computed from its children
+	Aggregate AggregateMode
+	// Weight is the weight of scope in the progress of a parent aggregating by weight,
+	// zero means the default weight.
+	Weight float64
+}
+
+// ScopeOption sets up an option of ScopeOptions
+type ScopeOption func(options *ScopeOptions)
+
+// NewScopeOptions applies all options to an empty ScopeOptions and returns it
+func NewScopeOptions(options ...ScopeOption) ScopeOptions {
+	result := ScopeOptions{}
+	for _, option := range options {
+		option(&result)
+	}
+	return result
+}
+
+// WithDecorators sets decorators displayed next to the progress bar of scope. Calling it
+// without decorators hides the decorators configured in renderer.
+func WithDecorators(decorators ...BarDecorator) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.Decorators = append([]BarDecorator{}, decorators...)
+	}
+}
+
+// BarColors defines the colors of a progress bar. Colors are ANSI color codes like
+// terminal.GreenColor, and a nil or negative color leaves the part uncolored, so that
+// the zero value of BarColors colors nothing.
+type BarColors struct {
+	// Filled is the color of the finished part of bar, like BarColor(terminal.GreenColor)
+	Filled *int
+	// Empty is the color of the unfinished part of bar
+	Empty *int
+	// Gradient replaces Filled if it's not empty: the progress range is split evenly by the
+	// colors, and the finished part takes the color of range the current percentage falls in.
+	Gradient []int
+}
+
+// BarColor returns color for the fields of BarColors
+func BarColor(color int) *int {
+	return &color
+}
+
+// WithBarStyle sets the style of progress bar of scope, the style must have five runes: left
+// boundary, fill, tip, space and right boundary, like "[=>-]". They may be followed by the runes
+// drawing fractions of a cell with sub-cell precision, from the smallest fraction to a full cell,
+// like "[=>-].:=". Styles without them use Unicode eighth blocks, or whole cells if they're ASCII.
+func WithBarStyle(style string) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.BarStyle = style
+	}
+}
+
+// WithBarColors sets the colors of progress bar of scope
+func WithBarColors(colors BarColors) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.BarColors = &colors
+	}
+}
+
+// WithSubCellPrecision sets whether progress bar of scope uses Unicode eighth blocks to
+// draw fractions of a cell.
+func WithSubCellPrecision(enabled bool) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.SubCellPrecision = &enabled
+	}
+}
+
+// WithAggregate makes the scope display a progress bar computed from its children by mode
+func WithAggregate(mode AggregateMode) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.Aggregate = mode
+	}
+}
+
+// WithWeight sets the weight of scope in the progress of a parent aggregating by weight
+func WithWeight(weight float64) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.Weight = weight
+	}
+}
@@ -0,0 +1,146 @@
+package echelon
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// proxyReportInterval is the minimal time gap between two progress reports of a proxy,
+// so that every Read or Write won't become a progress event.
+const proxyReportInterval = 100 * time.Millisecond
+
+// progressProxy counts transferred bytes and reports them to the bar of logger, nothing is reported
+// if the size is unknown since logger has no bar
+type progressProxy struct {
+	lock       sync.Mutex
+	logger     *Logger
+	sized      bool
+	pending    int64
+	lastReport time.Time
+	finished   bool
+}
+
+// transferred adds n transferred bytes, it reports them if the last report is old enough.
+func (proxy *progressProxy) transferred(n int) {
+	if n <= 0 {
+		return
+	}
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+	proxy.pending += int64(n)
+	if time.Since(proxy.lastReport) >= proxyReportInterval {
+		proxy.flush()
+	}
+}
+
+// flush reports all pending bytes, the lock must be held by caller
+func (proxy *progressProxy) flush() {
+	if proxy.pending == 0 || proxy.finished || !proxy.sized {
+		return
+	}
+	proxy.logger.AddProgress(proxy.pending)
+	proxy.pending = 0
+	proxy.lastReport = time.Now()
+}
+
+// proxyScope starts the scope of a proxy, it has a progress bar of size total unless total is 0 or less,
+// in which case the size is unknown and the scope only shows that it's running
+func proxyScope(logger *Logger, total int64, scope string) *Logger {
+	if total <= 0 {
+		return logger.Scoped(scope)
+	}
+	return logger.BarWithSize(total, scope)
+}
+
+// finish reports pending bytes and finishes the scope, only the first call takes effect.
+func (proxy *progressProxy) finish(success bool) {
+	proxy.lock.Lock()
+	defer proxy.lock.Unlock()
+	if proxy.finished {
+		return
+	}
+	proxy.flush()
+	proxy.finished = true
+	proxy.logger.Finish(success)
+}
+
+// proxyReader is a io.ReadCloser which reports progress of reading
+type proxyReader struct {
+	progressProxy
+	reader io.Reader
+}
+
+// ProxyReader creates a node with progress bar of size total and name (scope), and returns a reader
+// which reads from r and adds the read bytes to the progress.
+//
+// The scope will succeed once r returns io.EOF or the reader is closed, and fails if r returns any
+// other error. Closing the returned reader closes r as well if r is an io.Closer. If total is 0 or
+// less the size is unknown, the scope has no progress bar and only shows that it's running.
+func (logger *Logger) ProxyReader(r io.Reader, total int64, scope string) io.ReadCloser {
+	return &proxyReader{
+		progressProxy: progressProxy{logger: proxyScope(logger, total, scope), sized: total > 0},
+		reader:        r,
+	}
+}
+
+// Read reads from the underlying reader and reports the progress
+func (r *proxyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.transferred(n)
+	if err == io.EOF {
+		r.finish(true)
+	} else if err != nil {
+		r.finish(false)
+	}
+	return n, err
+}
+
+// Close closes the underlying reader and finishes the scope
+func (r *proxyReader) Close() error {
+	var err error
+	if closer, ok := r.reader.(io.Closer); ok {
+		err = closer.Close()
+	}
+	r.finish(err == nil)
+	return err
+}
+
+// proxyWriter is a io.WriteCloser which reports progress of writing
+type proxyWriter struct {
+	progressProxy
+	writer io.Writer
+}
+
+// ProxyWriter creates a node with progress bar of size total and name (scope), and returns a writer
+// which writes to w and adds the written bytes to the progress.
+//
+// The scope will succeed once the writer is closed, and fails if w returns an error. Closing the
+// returned writer closes w as well if w is an io.Closer. If total is 0 or less the size is unknown,
+// the scope has no progress bar and only shows that it's running.
+func (logger *Logger) ProxyWriter(w io.Writer, total int64, scope string) io.WriteCloser {
+	return &proxyWriter{
+		progressProxy: progressProxy{logger: proxyScope(logger, total, scope), sized: total > 0},
+		writer:        w,
+	}
+}
+
+// Write writes to the underlying writer and reports the progress
+func (w *proxyWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.transferred(n)
+	if err != nil {
+		w.finish(false)
+	}
+	return n, err
+}
+
+// Close closes the underlying writer and finishes the scope
+func (w *proxyWriter) Close() error {
+	var err error
+	if closer, ok := w.writer.(io.Closer); ok {
+		err = closer.Close()
+	}
+	w.finish(err == nil)
+	return err
+}
@@ -0,0 +1,80 @@
+package echelon_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingReader returns err once its content is read
+type failingReader struct {
+	content *strings.Reader
+	err     error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, _ := r.content.Read(p)
+	if n == 0 {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+// closingWriter records whether it has been closed
+type closingWriter struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func Test_Logger_ProxyReader(t *testing.T) {
+	var content []byte
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		var err error
+		content, err = ioutil.ReadAll(logger.ProxyReader(strings.NewReader("0123456789"), 10, "download"))
+		assert.NoError(t, err)
+	})
+	assert.Equal(t, "0123456789", string(content))
+	assert.Equal(t, []string{"started download", "progress download +10", "succeeded download"}, lines)
+}
+
+func Test_Logger_ProxyReader_Error(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		reader := logger.ProxyReader(&failingReader{strings.NewReader("012"), errors.New("reset")}, 10, "download")
+		_, err := ioutil.ReadAll(reader)
+		assert.EqualError(t, err, "reset")
+		assert.NoError(t, reader.Close())
+	})
+	assert.Equal(t, []string{"started download", "progress download +3", "failed download"}, lines)
+}
+
+func Test_Logger_ProxyWriter(t *testing.T) {
+	destination := &closingWriter{}
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		writer := logger.ProxyWriter(destination, 7, "upload")
+		_, _ = writer.Write([]byte("012"))
+		_, _ = writer.Write([]byte("3456"))
+		assert.NoError(t, writer.Close())
+		assert.NoError(t, writer.Close())
+	})
+	assert.Equal(t, "0123456", destination.String())
+	assert.True(t, destination.closed)
+	assert.Equal(t, []string{"started upload", "progress upload +3", "progress upload +4", "succeeded upload"}, lines)
+}
+
+func Test_Logger_ProxyReader_UnknownSize(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		_, err := ioutil.ReadAll(logger.ProxyReader(strings.NewReader("0123456789"), 0, "stream"))
+		assert.NoError(t, err)
+	})
+	assert.Equal(t, []string{"started stream", "succeeded stream"}, lines)
+}
@@ -0,0 +1,178 @@
+package echelon
+
+import (
+	"sync"
+	"time"
+
+	"github.com/roberChen/echelon/utils"
+)
+
+// defaultSuppressionReportInterval is the report interval of suppressed messages if RateLimit doesn't set one
+const defaultSuppressionReportInterval = time.Second
+
+// RateLimit limits the messages of every scope with a token bucket
+type RateLimit struct {
+	// MessagesPerSecond is the rate the tokens of a scope refill, every message takes a token
+	MessagesPerSecond float64
+	// Burst is the maximal number of tokens of a scope, it's the number of messages which can
+	// be sent at once
+	Burst int
+	// SampleEvery keeps every n-th message of the ones over the limit, 0 drops all of them
+	SampleEvery int
+	// ReportInterval is the minimal time between two lines about the number of suppressed
+	// messages of a scope, 0 means a second. Lines are sent along with messages of the scope,
+	// by a timer once the interval has passed if the scope keeps quiet, and when it finishes.
+	ReportInterval time.Duration
+}
+
+// rateLimiter is the token bucket of a scope, it's coroutine safe
+type rateLimiter struct {
+	lock  sync.Mutex
+	limit RateLimit
+	// now returns the current time of limiter
+	now func() time.Time
+	// report sends the line about suppressed messages reported by timer
+	report     func(suppressed int64)
+	tokens     float64
+	lastRefill time.Time
+	// overLimit is the number of messages over the limit, for sampling
+	overLimit int
+	// suppressed is the number of suppressed messages not reported yet
+	suppressed int64
+	lastReport time.Time
+	// timer reports suppressed messages of a quiet scope, it's nil if no report is scheduled
+	timer   *time.Timer
+	stopped bool
+}
+
+// newRateLimiter creates a full token bucket of limit, of which time is returned by now, suppressed
+// messages of quiet scopes are sent by report.
+func newRateLimiter(limit RateLimit, now func() time.Time, report func(suppressed int64)) *rateLimiter {
+	if limit.ReportInterval <= 0 {
+		limit.ReportInterval = defaultSuppressionReportInterval
+	}
+	start := now()
+	return &rateLimiter{
+		limit:      limit,
+		now:        now,
+		report:     report,
+		tokens:     float64(limit.Burst),
+		lastRefill: start,
+		lastReport: start,
+	}
+}
+
+// allow returns whether a message can be sent, and the number of suppressed messages which
+// should be reported now.
+func (limiter *rateLimiter) allow() (bool, int64) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	now := limiter.now()
+	limiter.tokens += now.Sub(limiter.lastRefill).Seconds() * limiter.limit.MessagesPerSecond
+	if limiter.tokens > float64(limiter.limit.Burst) {
+		limiter.tokens = float64(limiter.limit.Burst)
+	}
+	limiter.lastRefill = now
+	allowed := true
+	if limiter.tokens >= 1 {
+		limiter.tokens--
+	} else {
+		limiter.overLimit++
+		sampled := limiter.limit.SampleEvery > 0 && limiter.overLimit%limiter.limit.SampleEvery == 0
+		if !sampled {
+			allowed = false
+			limiter.suppressed++
+		}
+	}
+	if limiter.suppressed == 0 {
+		return allowed, 0
+	}
+	if now.Sub(limiter.lastReport) < limiter.limit.ReportInterval {
+		limiter.schedule(now)
+		return allowed, 0
+	}
+	return allowed, limiter.takeSuppressed(now)
+}
+
+// schedule starts the timer reporting suppressed messages once ReportInterval has passed since the
+// last report, unless it has started. The lock must be held by caller.
+func (limiter *rateLimiter) schedule(now time.Time) {
+	if limiter.timer != nil || limiter.stopped {
+		return
+	}
+	delay := limiter.lastReport.Add(limiter.limit.ReportInterval).Sub(now)
+	limiter.timer = time.AfterFunc(delay, limiter.reportPending)
+}
+
+// reportPending sends the line about suppressed messages not reported yet, it's called by timer.
+// The line is sent with the lock held, so that it's never sent after the scope has finished.
+func (limiter *rateLimiter) reportPending() {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	limiter.timer = nil
+	if limiter.stopped || limiter.suppressed == 0 {
+		return
+	}
+	limiter.report(limiter.takeSuppressed(limiter.now()))
+}
+
+// takeSuppressed returns the number of suppressed messages not reported yet and marks them reported,
+// the lock must be held by caller
+func (limiter *rateLimiter) takeSuppressed(now time.Time) int64 {
+	suppressed := limiter.suppressed
+	limiter.suppressed = 0
+	limiter.lastReport = now
+	return suppressed
+}
+
+// stop stops the timer and returns the number of suppressed messages not reported yet, which are
+// marked reported. The limiter reports nothing by timer after it's stopped.
+func (limiter *rateLimiter) stop() int64 {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	limiter.stopped = true
+	if limiter.timer != nil {
+		limiter.timer.Stop()
+		limiter.timer = nil
+	}
+	return limiter.takeSuppressed(limiter.now())
+}
+
+// SetRateLimit limits the messages of logger and scopes created later by it, every scope has its own
+// token bucket. Messages over the limit are dropped, and a line like "suppressed 1,234 messages" is
+// sent once ReportInterval has passed since the last line, along with a later message of the scope or
+// by a timer if the scope keeps quiet, and before the scope finishes, so dropped data is noticed.
+func (logger *Logger) SetRateLimit(limit RateLimit) {
+	logger.setRateLimit(limit, time.Now)
+}
+
+// setRateLimit sets the rate limit of logger, of which time is returned by now
+func (logger *Logger) setRateLimit(limit RateLimit, now func() time.Time) {
+	logger.rateLimit = &limit
+	logger.limiter = newRateLimiter(limit, now, logger.reportSuppressed)
+}
+
+// rateLimited returns whether a message of logger should be dropped by rate limit, it sends the line
+// about suppressed messages when it's time to report them.
+func (logger *Logger) rateLimited() bool {
+	if logger.limiter == nil {
+		return false
+	}
+	allowed, suppressed := logger.limiter.allow()
+	logger.reportSuppressed(suppressed)
+	return !allowed
+}
+
+// reportSuppressed sends a line about the number of suppressed messages if there are any
+func (logger *Logger) reportSuppressed(suppressed int64) {
+	if suppressed == 0 {
+		return
+	}
+	unit := "messages"
+	if suppressed == 1 {
+		unit = "message"
+	}
+	logger.entriesChannel <- &LogEvent{
+		LogEntry: NewLogEntryMessage(logger.scopes, WarnLevel, "suppressed %s %s", utils.FormatCount(suppressed), unit),
+	}
+}
@@ -0,0 +1,74 @@
+package echelon_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Logger_SetRateLimit(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.SetRateLimit(echelon.RateLimit{
+			MessagesPerSecond: 0.001,
+			Burst:             2,
+			SampleEvery:       3,
+			ReportInterval:    time.Hour,
+		})
+		noisy := logger.Scoped("noisy")
+		for i := 1; i <= 10; i++ {
+			noisy.Infof("line %d", i)
+		}
+		noisy.Debugf("filtered by level")
+		noisy.Finish(true)
+		quiet := logger.Scoped("quiet")
+		quiet.Infof("own bucket")
+		quiet.Finish(true)
+	})
+	assert.Equal(t, []string{
+		"started noisy", "info line 1", "info line 2", "info line 5", "info line 8",
+		"warn suppressed 6 messages", "succeeded noisy",
+		"started quiet", "info own bucket", "succeeded quiet",
+	}, lines)
+}
+
+func Test_Logger_SetRateLimit_ReportsPeriodically(t *testing.T) {
+	now := time.Now()
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.SetRateLimitWithClock(echelon.RateLimit{Burst: 1, ReportInterval: time.Minute}, func() time.Time {
+			return now
+		})
+		logger.Infof("first")
+		logger.Infof("dropped")
+		now = now.Add(30 * time.Second)
+		logger.Infof("dropped too")
+		now = now.Add(30 * time.Second)
+		logger.Infof("dropped again")
+		logger.Infof("dropped at last")
+	})
+	assert.Equal(t, []string{"info first", "warn suppressed 3 messages", "warn suppressed 1 message"}, lines)
+}
+
+func Test_Logger_SetRateLimit_ReportsQuietScope(t *testing.T) {
+	reported := make(chan struct{})
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddHook(func(event *echelon.LogEvent) []*echelon.LogEvent {
+			if event.LogEntry != nil && event.LogEntry.GetMessage() == "suppressed 1 message" {
+				close(reported)
+			}
+			return []*echelon.LogEvent{event}
+		})
+		logger.SetRateLimit(echelon.RateLimit{Burst: 1, ReportInterval: 10 * time.Millisecond})
+		quiet := logger.Scoped("quiet")
+		quiet.Infof("first")
+		quiet.Infof("dropped")
+		select {
+		case <-reported:
+		case <-time.After(5 * time.Second):
+			t.Error("suppressed messages of quiet scope aren't reported")
+		}
+		quiet.Finish(true)
+	})
+	assert.Equal(t, []string{"started quiet", "info first", "warn suppressed 1 message", "succeeded quiet"}, lines)
+}
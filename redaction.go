@@ -0,0 +1,102 @@
+package echelon
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactionMask replaces secrets in all texts sent to renderer
+const RedactionMask = "***"
+
+// redactor masks registered secrets and patterns in texts, it's coroutine safe
+type redactor struct {
+	lock     sync.RWMutex
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+// redact returns text with all secrets and matches of patterns replaced by RedactionMask
+func (r *redactor) redact(text string) string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, RedactionMask)
+	}
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllLiteralString(text, RedactionMask)
+	}
+	return text
+}
+
+// redactedError is an error whose message has secrets masked, it unwraps to the original error
+type redactedError struct {
+	message string
+	cause   error
+}
+
+func (err *redactedError) Error() string {
+	return err.message
+}
+
+func (err *redactedError) Unwrap() error {
+	return err.cause
+}
+
+// redactError returns err, or an error wrapping err with secrets masked if its message contains any
+func (r *redactor) redactError(err error) error {
+	if !r.enabled() {
+		return err
+	}
+	if message := r.redact(err.Error()); message != err.Error() {
+		return &redactedError{message: message, cause: err}
+	}
+	return err
+}
+
+// overlap returns the number of bytes at the end of a text which might be the beginning of a secret
+// completed by the text following it
+func (r *redactor) overlap() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if len(r.secrets) == 0 {
+		return 0
+	}
+	// secrets are sorted from the longest one
+	return len(r.secrets[0]) - 1
+}
+
+// enabled returns whether there's anything to redact
+func (r *redactor) enabled() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.secrets) > 0 || len(r.patterns) > 0
+}
+
+// AddSecret registers a secret string, it's masked in messages and scope names sent to renderer
+// by the logger and all other loggers of the same root from now on. Empty secret is ignored.
+//
+// Names of scopes are masked when the scopes are created, so that all events of a scope have the
+// same path. Scopes created before keep their names, add secrets before creating scopes named after them.
+func (logger *Logger) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	logger.redactor.lock.Lock()
+	defer logger.redactor.lock.Unlock()
+	logger.redactor.secrets = append(logger.redactor.secrets, secret)
+	// replace longer secrets first, so that secrets containing others are masked completely
+	sort.SliceStable(logger.redactor.secrets, func(i, j int) bool {
+		return len(logger.redactor.secrets[i]) > len(logger.redactor.secrets[j])
+	})
+}
+
+// AddSecretPattern registers a pattern of secrets, its matches are masked in messages and scope
+// names sent to renderer by the logger and all other loggers of the same root from now on. Like with
+// AddSecret, names of scopes created before are kept.
+func (logger *Logger) AddSecretPattern(pattern *regexp.Regexp) {
+	logger.redactor.lock.Lock()
+	defer logger.redactor.lock.Unlock()
+	logger.redactor.patterns = append(logger.redactor.patterns, pattern)
+}
@@ -0,0 +1,55 @@
+package echelon_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Logger_Redaction(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddSecret("hunter2")
+		logger.AddSecretPattern(regexp.MustCompile(`ghp_[A-Za-z0-9]+`))
+		scoped := logger.Scoped("login as hunter2")
+		scoped.Infof("token %s and password %s", "ghp_abc123", "hunter2")
+		writer := scoped.Writer(echelon.InfoLevel)
+		_, _ = writer.Write([]byte("split hun"))
+		_, _ = writer.Write([]byte("ter2\nlast ghp_"))
+		_, _ = writer.Write([]byte("xyz"))
+		_ = writer.Close()
+	})
+	assert.Equal(t, []string{
+		"started login as ***",
+		"info token *** and password ***",
+		"info split ***",
+		"info last ***",
+	}, lines)
+}
+
+func Test_Logger_Writer_LongLine(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		logger.AddSecret("hunter2")
+		writer := logger.Writer(echelon.InfoLevel)
+		_, _ = writer.Write([]byte(strings.Repeat("a", echelon.MaxWriterLine-2) + "hun"))
+		_, _ = writer.Write([]byte("ter2\n"))
+		_ = writer.Close()
+	})
+	assert.Equal(t, []string{"info " + strings.Repeat("a", echelon.MaxWriterLine-5), "info aaa***"}, lines)
+}
+
+func Test_Logger_Redaction_ExistingScope(t *testing.T) {
+	lines := run(echelon.InfoLevel, func(logger *echelon.Logger) {
+		push := logger.Scoped("push s3cr3t")
+		logger.AddSecret("s3cr3t")
+		push.Infof("pushing s3cr3t")
+		push.Finish(true)
+		logger.Scoped("verify s3cr3t").Finish(true)
+	})
+	assert.Equal(t, []string{
+		"started push s3cr3t", "info pushing ***", "succeeded push s3cr3t",
+		"started verify ***", "succeeded verify ***",
+	}, lines)
+}
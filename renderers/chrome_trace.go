@@ -0,0 +1,259 @@
+package renderers
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+)
+
+const (
+	// chromeTracePID is the process id of all events, a run is a single process in the trace
+	chromeTracePID = 1
+	// rootTrack is the track of top level scopes
+	rootTrack = 1
+)
+
+// chromeTraceEvent is an event of the Trace Event Format
+type chromeTraceEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat,omitempty"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	Duration  *int64                 `json:"dur,omitempty"`
+	PID       int                    `json:"pid"`
+	TID       int                    `json:"tid"`
+	Scope     string                 `json:"s,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	// path is the path of scope of event, its track is known once the run finishes
+	path string
+}
+
+// chromeTraceProgress is the progress state of a scope for counter events
+type chromeTraceProgress struct {
+	progress   int64
+	percentage int
+}
+
+// ChromeTraceRenderer is a renderer which writes the run in Trace Event Format JSON once the root finishes,
+// which can be loaded into chrome://tracing or Perfetto. Every scope becomes a duration event nested by
+// scope path, concurrent siblings are placed on separate tracks. Messages become instant events with
+// their level and text, and progress updates become counter events.
+type ChromeTraceRenderer struct {
+	out      io.Writer
+	start    time.Time
+	timeline *timeline.Recorder
+	// events are the instant and counter events, in arrival order
+	events     []*chromeTraceEvent
+	progresses map[string]*chromeTraceProgress
+	// lock guards events, progresses, err and the spans of timeline
+	lock sync.Mutex
+	err  error
+}
+
+// NewChromeTraceRenderer creates a renderer writing the trace to out
+func NewChromeTraceRenderer(out io.Writer) *ChromeTraceRenderer {
+	return &ChromeTraceRenderer{
+		out:        out,
+		start:      time.Now(),
+		timeline:   timeline.NewRecorder(0),
+		progresses: make(map[string]*chromeTraceProgress),
+	}
+}
+
+// RenderScopeStarted records the start of scope
+func (r *ChromeTraceRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	if len(entry.GetScopes()) > 0 {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.timeline.Start(entry.GetScopes())
+	}
+}
+
+// RenderScopeFinished records the end of scope, the trace is written once the root finishes
+func (r *ChromeTraceRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.timeline.Finish(entry)
+	if len(entry.GetScopes()) == 0 {
+		r.err = r.write()
+	}
+}
+
+// Err returns the error of writing the trace once the root has finished
+func (r *ChromeTraceRenderer) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// RenderMessage records message as an instant event of its scope
+func (r *ChromeTraceRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	args := map[string]interface{}{
+		"level":   entry.Level.String(),
+		"message": entry.GetMessage(),
+	}
+	for key, value := range entry.Fields {
+		if _, ok := args[key]; !ok {
+			args[key] = value
+		}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, &chromeTraceEvent{
+		Name:      entry.GetMessage(),
+		Category:  entry.Level.String(),
+		Phase:     "i",
+		Timestamp: r.timestamp(entry.GetTime()),
+		PID:       chromeTracePID,
+		Scope:     "t",
+		Args:      args,
+		path:      strings.Join(entry.GetScopes(), "/"),
+	})
+}
+
+// RenderProcess records the progress of scope as a counter event
+func (r *ChromeTraceRenderer) RenderProcess(entry *echelon.LogProcessMessage) {
+	path := strings.Join(entry.GetScopes(), "/")
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	state, ok := r.progresses[path]
+	if !ok {
+		state = &chromeTraceProgress{}
+		r.progresses[path] = state
+	}
+	args := make(map[string]interface{})
+	if entry.Progress != 0 || entry.Addprogress != 0 {
+		if entry.Progress != 0 {
+			state.progress = entry.Progress
+		}
+		state.progress += entry.Addprogress
+		args["progress"] = state.progress
+	}
+	if entry.Percentage != 0 || entry.Addpercentage != 0 {
+		if entry.Percentage != 0 {
+			state.percentage = entry.Percentage
+		}
+		state.percentage += entry.Addpercentage
+		args["percentage"] = state.percentage
+	}
+	if len(args) == 0 {
+		return
+	}
+	r.events = append(r.events, &chromeTraceEvent{
+		Name:      path,
+		Phase:     "C",
+		Timestamp: r.timestamp(time.Now()),
+		PID:       chromeTracePID,
+		Args:      args,
+		path:      path,
+	})
+}
+
+// Write writes the trace of everything recorded so far to out, scopes which are still running last until now.
+// It's a coroutine safe function.
+func (r *ChromeTraceRenderer) Write() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.write()
+}
+
+// write writes the trace to out, the lock must be held by caller
+func (r *ChromeTraceRenderer) write() error {
+	_, end := r.timeline.Bounds()
+	tracks := assignTracks(r.timeline.Root())
+	// messages of root are placed on the track of top level scopes
+	trackOf := map[string]int{"": rootTrack}
+	events := []*chromeTraceEvent{{
+		Name:  "process_name",
+		Phase: "M",
+		PID:   chromeTracePID,
+		Args:  map[string]interface{}{"name": "echelon"},
+	}}
+	for _, span := range r.timeline.Spans() {
+		trackOf[span.Path()] = tracks[span]
+	}
+	walkSpans(r.timeline.Root(), func(span *timeline.Span) {
+		duration := r.timestamp(spanEnd(span, end)) - r.timestamp(span.Start)
+		args := map[string]interface{}{
+			"path":    span.Path(),
+			"outcome": span.Outcome.String(),
+		}
+		if span.Cause != "" {
+			args["cause"] = span.Cause
+		}
+		events = append(events, &chromeTraceEvent{
+			Name:      span.Scopes[len(span.Scopes)-1],
+			Phase:     "X",
+			Timestamp: r.timestamp(span.Start),
+			Duration:  &duration,
+			PID:       chromeTracePID,
+			TID:       tracks[span],
+			Args:      args,
+		})
+	})
+	for _, event := range r.events {
+		event.TID = trackOf[event.path]
+		events = append(events, event)
+	}
+	return json.NewEncoder(r.out).Encode(map[string]interface{}{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+}
+
+// timestamp returns microseconds from the start of renderer to t
+func (r *ChromeTraceRenderer) timestamp(t time.Time) int64 {
+	return t.Sub(r.start).Microseconds()
+}
+
+// walkSpans calls f for all nested spans of span, every span is followed by its children
+func walkSpans(span *timeline.Span, f func(span *timeline.Span)) {
+	for _, child := range span.Children {
+		f(child)
+		walkSpans(child, f)
+	}
+}
+
+// assignTracks returns the tracks of all nested spans of root, so that spans on the same track are either
+// nested or sequential. A span shares the track of its parent unless a sibling on that track overlaps it,
+// in which case it takes the first track of its earlier siblings which is free, or a new one.
+func assignTracks(root *timeline.Span) map[*timeline.Span]int {
+	tracks := make(map[*timeline.Span]int)
+	next := rootTrack
+	var assign func(span *timeline.Span, track int)
+	assign = func(span *timeline.Span, track int) {
+		children := append([]*timeline.Span(nil), span.Children...)
+		sort.SliceStable(children, func(i, j int) bool {
+			return children[i].Start.Before(children[j].Start)
+		})
+		candidates := []int{track}
+		// last are the last children placed on tracks
+		last := make(map[int]*timeline.Span)
+		for _, child := range children {
+			chosen := -1
+			for _, candidate := range candidates {
+				previous, ok := last[candidate]
+				if !ok || (!previous.End.IsZero() && !previous.End.After(child.Start)) {
+					chosen = candidate
+					break
+				}
+			}
+			if chosen < 0 {
+				next++
+				chosen = next
+				candidates = append(candidates, chosen)
+			}
+			last[chosen] = child
+			tracks[child] = chosen
+			assign(child, chosen)
+		}
+	}
+	assign(root, next)
+	return tracks
+}
@@ -0,0 +1,85 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ChromeTraceRenderer(t *testing.T) {
+	var out bytes.Buffer
+	r := NewChromeTraceRenderer(&out)
+	paths := [][]string{{"a"}, {"a", "b"}, {"a", "c"}, {"a", "d"}, {"e"}}
+	for _, scopes := range paths {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, scopes...))
+	}
+	message := echelon.NewLogEntryMessage([]string{"a", "c"}, echelon.WarnLevel, "slow")
+	message.SetField("attempt", 2)
+	r.RenderMessage(message)
+	for i := 0; i < 2; i++ {
+		progress := echelon.NewLogProcessMessage("a")
+		progress.Addprogress = 10
+		r.RenderProcess(progress)
+	}
+	for i := len(paths) - 1; i >= 0; i-- {
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(paths[i][0] != "e", paths[i]...))
+	}
+	bounds := map[string][2]time.Duration{"a": {0, 10}, "a/b": {0, 4}, "a/c": {2, 6}, "a/d": {5, 8}, "e": {1, 3}}
+	for _, span := range r.timeline.Spans() {
+		span.Start = r.start.Add(bounds[span.Path()][0] * time.Second)
+		span.End = r.start.Add(bounds[span.Path()][1] * time.Second)
+	}
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.NoError(t, r.Err())
+
+	var trace struct {
+		TraceEvents []struct {
+			Name  string                 `json:"name"`
+			Phase string                 `json:"ph"`
+			TS    int64                  `json:"ts"`
+			Dur   int64                  `json:"dur"`
+			TID   int                    `json:"tid"`
+			Args  map[string]interface{} `json:"args"`
+		} `json:"traceEvents"`
+	}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &trace))
+	events := trace.TraceEvents
+	assert.Len(t, events, 9)
+	assert.Equal(t, "M", events[0].Phase)
+	tracks := map[string]int{}
+	for _, event := range events[1:6] {
+		assert.Equal(t, "X", event.Phase)
+		tracks[event.Args["path"].(string)] = event.TID
+	}
+	assert.Equal(t, map[string]int{"a": 1, "a/b": 1, "a/c": 2, "a/d": 1, "e": 3}, tracks)
+	assert.Equal(t, int64(2000000), events[3].TS)
+	assert.Equal(t, int64(4000000), events[3].Dur)
+	assert.Equal(t, "failed", events[5].Args["outcome"])
+	assert.Equal(t, "i", events[6].Phase)
+	assert.Equal(t, 2, events[6].TID)
+	assert.Equal(t, "warn", events[6].Args["level"])
+	assert.Equal(t, float64(2), events[6].Args["attempt"])
+	assert.Equal(t, "C", events[8].Phase)
+	assert.Equal(t, float64(20), events[8].Args["progress"])
+}
+
+// failingWriter fails all writes
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func Test_ChromeTraceRenderer_Err(t *testing.T) {
+	r := NewChromeTraceRenderer(failingWriter{})
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "a"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "a"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.EqualError(t, r.Err(), "disk full")
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CollapseMode tells how consecutive repeated messages of a scope are collapsed into one line
+// with a counter, like "waiting for lock... (x42)"
+type CollapseMode int
+
+const (
+	// NoCollapse keeps all messages
+	NoCollapse CollapseMode = iota
+	// CollapseIdentical collapses consecutive identical messages
+	CollapseIdentical
+	// CollapseIgnoringDigits collapses consecutive messages which differ in digits only
+	CollapseIgnoringDigits
+)
+
+// RepeatKey returns the text of line compared by mode to find repeated lines
+func (mode CollapseMode) RepeatKey(line string) string {
+	if mode != CollapseIgnoringDigits {
+		return line
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return -1
+		}
+		return r
+	}, line)
+}
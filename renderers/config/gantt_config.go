@@ -0,0 +1,24 @@
+package config
+
+import (
+	"runtime"
+
+	"github.com/roberChen/echelon/terminal"
+)
+
+// GanttRendererConfig is a structure which defines config of gantt renderer
+type GanttRendererConfig struct {
+	Colors *terminal.ColorSchema
+	// Width is the width of chart, zero uses the width of terminal, or 80 columns if it's unknown
+	Width int
+	// ASCII draws the chart with ASCII characters only
+	ASCII bool
+}
+
+// NewDefaultGanttRendererConfig returns default config of gantt renderer for current system
+func NewDefaultGanttRendererConfig() *GanttRendererConfig {
+	return &GanttRendererConfig{
+		Colors: terminal.DefaultColorSchema(),
+		ASCII:  runtime.GOOS == "windows",
+	}
+}
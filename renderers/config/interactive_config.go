@@ -1,11 +1,19 @@
 package config
 
 import (
+	"github.com/roberChen/echelon"
 	"github.com/roberChen/echelon/terminal"
 	"runtime"
 	"time"
 )
 
+const (
+	// DefaultBarStyle is the default style of progress bars
+	DefaultBarStyle = "╢▌▌░╟"
+	// ASCIIBarStyle is a style of progress bars made of ASCII characters only, similar to the bar of wget
+	ASCIIBarStyle = "[=>-]"
+)
+
 // InteractiveRendererConfig is a structure which defines config of interactive renderer
 type InteractiveRendererConfig struct {
 	Colors                         *terminal.ColorSchema
@@ -14,7 +22,53 @@ type InteractiveRendererConfig struct {
 	ProgressIndicatorCycleDuration time.Duration
 	SuccessStatus                  string
 	FailureStatus                  string
+	SkippedStatus                  string
 	DescriptionLinesWhenFailed     int
+	// FilterLevel enables filtering of messages by Level, all messages are rendered without it. Deferred
+	// messages of failed scopes are never filtered, see (*echelon.Logger).DeferLevel
+	FilterLevel bool
+	// Level is the most verbose level of messages rendered if FilterLevel is set, independent of the level of logger
+	Level echelon.LogLevel
+	// LevelBadges adds badges of level to messages, like "[WARN]"
+	LevelBadges bool
+	// CollapseRepeatedMessages collapses consecutive repeated lines of a scope into one line with a counter
+	CollapseRepeatedMessages CollapseMode
+	// OutputOnFailureOnly makes failed scopes show all their output kept in memory (see DescriptionBufferLines)
+	// in the final frame, instead of the last DescriptionLinesWhenFailed lines. Succeeded scopes show only their
+	// titles as always.
+	OutputOnFailureOnly bool
+	// BarDecorators are displayed next to progress bars whose scopes don't specify decorators
+	BarDecorators []echelon.BarDecorator
+	// BarStyle is the five runes style of progress bars: left boundary, fill, tip, space and right boundary,
+	// optionally followed by sub-cell runes, see echelon.WithBarStyle
+	BarStyle string
+	// BarColors are the colors of progress bars, nil leaves progress bars uncolored
+	BarColors *echelon.BarColors
+	// BarSubCellPrecision makes progress bars draw fractions of a cell with the sub-cell runes of their styles
+	BarSubCellPrecision bool
+	// DescriptionBufferLines is the number of last lines of output kept in memory for every scope, zero
+	// keeps DescriptionLinesWhenFailed lines and a negative number keeps all lines
+	DescriptionBufferLines int
+	// DescriptionSpill writes the full output of every scope to a temp file, whose path is shown
+	// when the scope fails. The file is removed once the scope or a scope enclosing it succeeds, and when
+	// the run stops before the scope finishes.
+	DescriptionSpill bool
+	// DescriptionSpillDirectory is the directory of temp files of DescriptionSpill, the default
+	// directory for temp files is used if it's empty
+	DescriptionSpillDirectory string
+	// LogDirectory is the run directory where the complete messages of every scope are written to their own
+	// files, with an index file mapping scopes to files and outcomes written once the root finishes. Failed
+	// scopes show the paths of their files instead of temp files of DescriptionSpill. Empty means no log files.
+	LogDirectory string
+	// FailureReport prints a report once the run finishes: every failed scope with its path, duration, cause
+	// and last FailureReportLines lines of output, followed by the numbers of scopes by outcome and the wall time
+	FailureReport      bool
+	FailureReportLines int
+	// SlowestScopes is the number of slowest leaf scopes, which have no nested scopes, listed in a table at the
+	// end of run, zero disables the table
+	SlowestScopes int
+	// CriticalPath prints the chain of nested and sequential scopes which determined the wall time at the end of run
+	CriticalPath bool
 }
 
 // NewDefaultRenderingConfig returns default config for current system
@@ -37,7 +91,10 @@ func NewDefaultUnixRenderingConfig() *InteractiveRendererConfig {
 		ProgressIndicatorCycleDuration: time.Second,
 		SuccessStatus:                  "✅",
 		FailureStatus:                  "❌",
+		SkippedStatus:                  "⏭",
 		DescriptionLinesWhenFailed:     100,
+		BarStyle:                       DefaultBarStyle,
+		FailureReportLines:             10,
 	}
 }
 
@@ -53,7 +110,10 @@ func NewDefaultWindowsRenderingConfig() *InteractiveRendererConfig {
 		ProgressIndicatorCycleDuration: time.Second,
 		SuccessStatus:                  "+",
 		FailureStatus:                  "-",
+		SkippedStatus:                  "~",
 		DescriptionLinesWhenFailed:     100,
+		BarStyle:                       ASCIIBarStyle,
+		FailureReportLines:             10,
 	}
 }
 
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// DefaultOTLPTimeout is the default timeout of requests to the collector endpoint of OTLP renderer
+const DefaultOTLPTimeout = 10 * time.Second
+
+// OTLPRendererConfig is a structure which defines config of OTLP renderer, spans are exported to Endpoint
+// if it's set, otherwise they're written to File. Nothing is exported without both.
+type OTLPRendererConfig struct {
+	// Endpoint is the URL spans are posted to as OTLP/JSON, like "http://localhost:4318/v1/traces"
+	Endpoint string
+	// Headers are added to requests to Endpoint, like authorization headers of collector
+	Headers map[string]string
+	// Timeout is the timeout of requests to Endpoint, spans are exported while the root finishes so a slow
+	// collector blocks rendering for up to Timeout. Zero means DefaultOTLPTimeout.
+	Timeout time.Duration
+	// File is the path of file spans are written to as OTLP/JSON
+	File string
+	// ServiceName is the name of service in resource of spans, and the name of span of root
+	ServiceName string
+}
+
+// NewDefaultOTLPRendererConfig returns default config of OTLP renderer writing spans to file
+func NewDefaultOTLPRendererConfig(file string) *OTLPRendererConfig {
+	//nolint:gomnd
+	return &OTLPRendererConfig{
+		Timeout:     DefaultOTLPTimeout,
+		File:        file,
+		ServiceName: "echelon",
+	}
+}
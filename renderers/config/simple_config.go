@@ -0,0 +1,84 @@
+package config
+
+import (
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/terminal"
+)
+
+// DefaultGroupedMemoryLimit is the default maximal bytes of output buffered in memory in grouped or failure
+// only mode
+const DefaultGroupedMemoryLimit = 4 << 20
+
+// SimpleRendererConfig is a structure which defines config of simple renderer
+type SimpleRendererConfig struct {
+	Colors *terminal.ColorSchema
+	// FilterLevel enables filtering of messages by Level, all messages are rendered without it. Deferred
+	// messages of failed scopes are never filtered, see (*echelon.Logger).DeferLevel
+	FilterLevel bool
+	// Level is the most verbose level of messages rendered if FilterLevel is set, independent of the level of logger
+	Level echelon.LogLevel
+	// LevelBadges adds badges of level to messages, like "[WARN]"
+	LevelBadges bool
+	// CollapseRepeatedMessages collapses consecutive repeated messages of a scope, the first message is
+	// printed and the repetitions are summarized by a line with a counter once another message comes.
+	CollapseRepeatedMessages CollapseMode
+	// ProgressStep prints a progress line every time the progress of a scope reaches a new
+	// multiple of the step in percent, 0 disables it.
+	ProgressStep int
+	// ProgressInterval prints a progress line of a scope at most once per interval, 0 disables it.
+	ProgressInterval time.Duration
+	// Prefix prefixes every line with the path of scope which produced it, in a stable color per scope
+	Prefix bool
+	// PrefixAbbreviate shortens all but the last scope of prefix path to their first characters
+	PrefixAbbreviate bool
+	// PrefixWidth pads or truncates scope paths of prefixes to the width, 0 keeps them as is
+	PrefixWidth int
+	// PrefixColors are the colors scope paths of prefixes are picked from
+	PrefixColors []int
+	// PrefixLevel adds level of messages to prefixes
+	PrefixLevel bool
+	// PrefixTimestampFormat adds time of lines formatted with the layout to prefixes, empty disables it
+	PrefixTimestampFormat string
+	// Grouped buffers all output of a scope and writes it as one block when the scope finishes,
+	// the output of nested scopes is placed inside the block of their parent in start order.
+	Grouped bool
+	// OutputOnFailureOnly buffers all output of a scope, a succeeded scope prints only its result line
+	// while a failed scope prints all its buffered output.
+	OutputOnFailureOnly bool
+	// GroupedMemoryLimit is the maximal bytes of output buffered in memory in grouped or failure only
+	// mode, buffers spill to a single temp file once it's exceeded. 0 means there is no limitation, the default
+	// config limits it to DefaultGroupedMemoryLimit.
+	GroupedMemoryLimit int
+	// SpillDirectory is the directory of the temp file of spilled buffers, empty means the default
+	// directory for temp files.
+	SpillDirectory string
+	// LogDirectory is the run directory where the complete messages of every scope are written to their own
+	// files, with an index file mapping scopes to files and outcomes written once the root finishes. Failed
+	// scopes show the paths of their files. Empty means no log files.
+	LogDirectory string
+	// FailureReport prints a report once the root finishes: every failed scope with its path, duration, cause
+	// and last FailureReportLines lines of output, followed by the numbers of scopes by outcome and the wall time
+	FailureReport      bool
+	FailureReportLines int
+	// SlowestScopes is the number of slowest leaf scopes, which have no nested scopes, listed in a table at the
+	// end of run, zero disables the table
+	SlowestScopes int
+	// CriticalPath prints the chain of nested and sequential scopes which determined the wall time at the end of run
+	CriticalPath bool
+}
+
+// NewDefaultSimpleRendererConfig returns default config of simple renderer
+func NewDefaultSimpleRendererConfig() *SimpleRendererConfig {
+	//nolint:gomnd
+	return &SimpleRendererConfig{
+		Colors:             terminal.DefaultColorSchema(),
+		ProgressStep:       10,
+		FailureReportLines: 10,
+		GroupedMemoryLimit: DefaultGroupedMemoryLimit,
+		PrefixColors: []int{
+			terminal.CyanColor, terminal.YellowColor, terminal.GreenColor, terminal.MagentaColor, terminal.BlueColor,
+		},
+	}
+}
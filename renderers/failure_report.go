@@ -0,0 +1,49 @@
+package renderers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+	"github.com/roberChen/echelon/terminal"
+	"github.com/roberChen/echelon/utils"
+)
+
+// writeFailureReport writes the end of run report to out: every failed scope with its path, duration,
+// cause and last lines of output, followed by the numbers of scopes by outcome and the wall time.
+func writeFailureReport(out io.Writer, colors *terminal.ColorSchema, recorder *timeline.Recorder) {
+	var report strings.Builder
+	now := time.Now()
+	failed := false
+	for _, span := range recorder.Spans() {
+		if span.Outcome != timeline.Failed {
+			continue
+		}
+		if !failed {
+			report.WriteString("\nFailures:\n")
+			failed = true
+		}
+		line := fmt.Sprintf("%s failed in %s", span.Path(), utils.FormatDuration(span.Duration(now), true))
+		if span.Cause != "" {
+			line += ": " + span.Cause
+		}
+		report.WriteString(terminal.GetColoredText(colors.FailureColor, line) + "\n")
+		for _, output := range span.Lines() {
+			report.WriteString("    " + output + "\n")
+		}
+	}
+	totals := recorder.Totals()
+	summary := fmt.Sprintf("%d succeeded, %d failed, %d skipped", totals.Succeeded, totals.Failed, totals.Skipped)
+	if totals.Running > 0 {
+		summary += fmt.Sprintf(", %d unfinished", totals.Running)
+	}
+	summary += " in " + utils.FormatDuration(totals.Wall, true)
+	color := colors.SuccessColor
+	if totals.Failed > 0 {
+		color = colors.FailureColor
+	}
+	report.WriteString("\n" + terminal.GetColoredText(color, summary) + "\n")
+	_, _ = io.WriteString(out, report.String())
+}
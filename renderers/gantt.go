@@ -0,0 +1,209 @@
+package renderers
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/roberChen/echelon/renderers/internal/console"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+	"github.com/roberChen/echelon/terminal"
+	"github.com/roberChen/echelon/utils"
+)
+
+const (
+	// defaultGanttWidth is the width of chart if the width of terminal is unknown
+	defaultGanttWidth = 80
+	// minGanttBarWidth is the minimal width of the bars part of chart
+	minGanttBarWidth = 10
+)
+
+// GanttRenderer is a summary renderer which records when scopes start and finish, and draws a gantt chart
+// of all scopes over wall time once it stops drawing, so parallelism and idle gaps of a run are visible.
+// It's usually combined with another renderer by MultiRenderer.
+type GanttRenderer struct {
+	out      io.Writer
+	config   *config.GanttRendererConfig
+	timeline *timeline.Recorder
+	width    int
+	// lock guards the spans of timeline
+	lock sync.Mutex
+}
+
+// NewGanttRenderer creates a gantt renderer writing the chart to out
+func NewGanttRenderer(out io.Writer, rendererConfig *config.GanttRendererConfig) *GanttRenderer {
+	if rendererConfig == nil {
+		rendererConfig = config.NewDefaultGanttRendererConfig()
+	}
+	width := rendererConfig.Width
+	if file, ok := out.(*os.File); ok && width <= 0 {
+		width = console.TerminalWidth(file)
+	}
+	if width <= 0 {
+		width = defaultGanttWidth
+	}
+	return &GanttRenderer{
+		out:      out,
+		config:   rendererConfig,
+		timeline: timeline.NewRecorder(0),
+		width:    width,
+	}
+}
+
+// RenderScopeStarted records the start of scope
+func (r *GanttRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	if len(entry.GetScopes()) > 0 {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.timeline.Start(entry.GetScopes())
+	}
+}
+
+// RenderScopeFinished records the end and outcome of scope
+func (r *GanttRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.timeline.Finish(entry)
+}
+
+// RenderMessage does nothing, messages aren't part of the chart
+func (r *GanttRenderer) RenderMessage(entry *echelon.LogEntryMessage) {}
+
+// RenderProcess does nothing, progress isn't part of the chart
+func (r *GanttRenderer) RenderProcess(entry *echelon.LogProcessMessage) {}
+
+// StopDrawing draws the chart, it should be called once the root has finished
+func (r *GanttRenderer) StopDrawing() {
+	r.lock.Lock()
+	chart := r.chart()
+	r.lock.Unlock()
+	_, _ = io.WriteString(r.out, chart)
+}
+
+// ganttRow is a line of chart
+type ganttRow struct {
+	span     *timeline.Span
+	label    string
+	duration string
+}
+
+// chart returns the gantt chart of all scopes: a line for every scope with its indented name, a bar
+// from its start to its end colored by its outcome, and its duration, followed by a time axis. The lock
+// must be held by caller.
+func (r *GanttRenderer) chart() string {
+	start, end := r.timeline.Bounds()
+	rows := ganttRows(r.timeline.Root(), end)
+	if len(rows) == 0 {
+		return ""
+	}
+	labelWidth, durationWidth := 0, len(utils.FormatDuration(end.Sub(start), true))
+	for _, row := range rows {
+		if width := runewidth.StringWidth(row.label); width > labelWidth {
+			labelWidth = width
+		}
+		if len(row.duration) > durationWidth {
+			durationWidth = len(row.duration)
+		}
+	}
+	if labelWidth > r.width/3 {
+		labelWidth = r.width / 3
+	}
+	barWidth := r.width - labelWidth - durationWidth - 4
+	if barWidth < minGanttBarWidth {
+		barWidth = minGanttBarWidth
+	}
+	fill, border := "█", "│"
+	if r.config.ASCII {
+		fill, border = "#", "|"
+	}
+	total := float64(end.Sub(start))
+	var chart strings.Builder
+	chart.WriteString("\nTimeline:\n")
+	for _, row := range rows {
+		from, to := 0, barWidth
+		if total > 0 {
+			from = int(float64(row.span.Start.Sub(start)) / total * float64(barWidth))
+			to = int(math.Ceil(float64(spanEnd(row.span, end).Sub(start)) / total * float64(barWidth)))
+		}
+		from, to = clampCells(from, to, barWidth)
+		bar := strings.Repeat(" ", from) +
+			terminal.GetColoredText(r.outcomeColor(row.span.Outcome), strings.Repeat(fill, to-from)) +
+			strings.Repeat(" ", barWidth-to)
+		chart.WriteString(fmt.Sprintf("%s %s%s%s %*s\n", ganttLabel(row.label, labelWidth), border, bar, border,
+			durationWidth, row.duration))
+	}
+	wall := utils.FormatDuration(end.Sub(start), true)
+	gap := barWidth - len("0s") - len(wall)
+	if gap < 1 {
+		gap = 1
+	}
+	axis := "0s" + strings.Repeat(" ", gap) + wall
+	chart.WriteString(strings.Repeat(" ", labelWidth+2) + axis + "\n")
+	return chart.String()
+}
+
+// outcomeColor returns the color of bars of scopes with outcome
+func (r *GanttRenderer) outcomeColor(outcome timeline.Outcome) int {
+	switch outcome {
+	case timeline.Succeeded:
+		return r.config.Colors.SuccessColor
+	case timeline.Failed:
+		return r.config.Colors.FailureColor
+	case timeline.Skipped, timeline.Running:
+		return r.config.Colors.NeutralColor
+	}
+	return r.config.Colors.NeutralColor
+}
+
+// ganttRows returns rows of children of span and their nested scopes, every scope is followed by its
+// children. Running scopes last until end.
+func ganttRows(span *timeline.Span, end time.Time) []ganttRow {
+	var rows []ganttRow
+	for _, child := range span.Children {
+		rows = append(rows, ganttRow{
+			span:     child,
+			label:    strings.Repeat("  ", len(child.Scopes)-1) + child.Scopes[len(child.Scopes)-1],
+			duration: utils.FormatDuration(spanEnd(child, end).Sub(child.Start), true),
+		})
+		rows = append(rows, ganttRows(child, end)...)
+	}
+	return rows
+}
+
+// ganttLabel returns label padded to width, a longer label is cut at its end so that the indentation
+// of nesting is kept
+func ganttLabel(label string, width int) string {
+	return runewidth.FillRight(runewidth.Truncate(label, width, "…"), width)
+}
+
+// spanEnd returns the end of span, which is end if it's running
+func spanEnd(span *timeline.Span, end time.Time) time.Time {
+	if span.End.IsZero() {
+		return end
+	}
+	return span.End
+}
+
+// clampCells keeps the cells of a bar from 'from' to 'to' inside width, a bar has at least one cell
+func clampCells(from, to, width int) (int, int) {
+	if from >= width {
+		from = width - 1
+	}
+	if from < 0 {
+		from = 0
+	}
+	if to > width {
+		to = width
+	}
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
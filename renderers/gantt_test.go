@@ -0,0 +1,52 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/roberChen/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GanttRenderer(t *testing.T) {
+	var out bytes.Buffer
+	r := NewGanttRenderer(&out, &config.GanttRendererConfig{
+		Colors: &terminal.ColorSchema{SuccessColor: -1, FailureColor: -1, NeutralColor: -1},
+		Width:  40,
+		ASCII:  true,
+	})
+	var junit bytes.Buffer
+	multi := NewMultiRenderer(r, NewJUnitRenderer(&junit))
+	multi.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "a"))
+	multi.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "a", "b"))
+	multi.RenderScopeFinished(echelon.NewLogScopeFinished(true, "a", "b"))
+	multi.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "c"))
+	multi.RenderScopeFinished(echelon.NewLogScopeFinished(false, "c"))
+	multi.RenderScopeFinished(echelon.NewLogScopeFinished(true, "a"))
+	multi.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	base := time.Now()
+	bounds := map[string][2]time.Duration{"a": {0, 10}, "a/b": {0, 5}, "c": {5, 10}}
+	for _, span := range r.timeline.Spans() {
+		span.Start = base.Add(bounds[span.Path()][0] * time.Second)
+		span.End = base.Add(bounds[span.Path()][1] * time.Second)
+	}
+	r.timeline.Root().End = base.Add(10 * time.Second)
+	multi.StopDrawing()
+	assert.Equal(t, "\nTimeline:\n"+
+		"a   |"+strings.Repeat("#", 29)+"|  10s\n"+
+		"  b |"+strings.Repeat("#", 15)+strings.Repeat(" ", 14)+"| 5.0s\n"+
+		"c   |"+strings.Repeat(" ", 14)+strings.Repeat("#", 15)+"| 5.0s\n"+
+		"     0s"+strings.Repeat(" ", 24)+"10s\n",
+		strings.ReplaceAll(out.String(), terminal.ResetSequence, ""))
+	assert.Contains(t, junit.String(), `<testsuite name="c"`)
+}
+
+func Test_ganttLabel(t *testing.T) {
+	assert.Equal(t, "  compile…", ganttLabel("  compile everything", 10))
+	assert.Equal(t, "  test    ", ganttLabel("  test", 10))
+}
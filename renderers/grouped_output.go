@@ -0,0 +1,195 @@
+package renderers
+
+import (
+	"io"
+	"strings"
+
+	"github.com/roberChen/echelon/renderers/internal/spill"
+)
+
+// outputGroup is the buffered output of a scope
+type outputGroup struct {
+	path string
+	// segments are the text of scope and the groups of nested scopes, in start order
+	segments []groupSegment
+}
+
+// groupSegment is either a piece of text or the group of a nested scope
+type groupSegment struct {
+	text  *spill.Buffer
+	child *outputGroup
+}
+
+// groupedOutput buffers output of scopes until they finish, the groups of nested scopes are kept inside
+// groups of their parents. Once the memory of buffers exceeds memoryLimit, all buffers spill to a single
+// temp file in spillDirectory. Once spilling has failed, buffers are kept in memory without spilling again.
+type groupedOutput struct {
+	groups map[string]*outputGroup
+	// topLevel are the groups without parent groups, in start order
+	topLevel    []*outputGroup
+	memory      int
+	memoryLimit int
+	// spillFile is the temp file shared by all spilled buffers
+	spillFile *spill.File
+}
+
+// newGroupedOutput creates grouped output, zero memoryLimit means there is no limitation
+func newGroupedOutput(memoryLimit int, spillDirectory string) *groupedOutput {
+	return &groupedOutput{
+		groups:      make(map[string]*outputGroup),
+		memoryLimit: memoryLimit,
+		spillFile:   spill.NewFile(spillDirectory),
+	}
+}
+
+// start creates the group of scope with path 'scopes' inside the group of its parent if there's one
+func (output *groupedOutput) start(scopes []string) *outputGroup {
+	path := strings.Join(scopes, "/")
+	if group, ok := output.groups[path]; ok {
+		return group
+	}
+	group := &outputGroup{path: path}
+	output.groups[path] = group
+	if parent, ok := output.groups[strings.Join(scopes[:len(scopes)-1], "/")]; ok && len(scopes) > 1 {
+		parent.segments = append(parent.segments, groupSegment{child: group})
+	} else {
+		output.topLevel = append(output.topLevel, group)
+	}
+	return group
+}
+
+// write appends text to the group of scope with path 'scopes', the group is created if it doesn't exist.
+// It returns the error of spilling buffers if spilling has failed because of text.
+func (output *groupedOutput) write(scopes []string, text string) error {
+	group, ok := output.groups[strings.Join(scopes, "/")]
+	if !ok {
+		group = output.start(scopes)
+	}
+	last := len(group.segments) - 1
+	if last < 0 || group.segments[last].text == nil {
+		group.segments = append(group.segments, groupSegment{text: &spill.Buffer{}})
+		last++
+	}
+	buffer := group.segments[last].text
+	_, _ = buffer.WriteString(text)
+	if buffer.Spilled() {
+		return nil
+	}
+	output.memory += len(text)
+	if output.memoryLimit > 0 && output.memory > output.memoryLimit && output.spillFile.Err() == nil {
+		return output.spillAll()
+	}
+	return nil
+}
+
+// spillAll moves all buffers in memory to the temp file, it stops at the first error
+func (output *groupedOutput) spillAll() error {
+	for _, group := range output.groups {
+		for _, segment := range group.segments {
+			if segment.text == nil || segment.text.Spilled() {
+				continue
+			}
+			size := segment.text.Len()
+			if err := segment.text.Spill(output.spillFile); err != nil {
+				return err
+			}
+			output.memory -= size
+		}
+	}
+	return nil
+}
+
+// finish writes the group of scope with path 'scopes' to out and releases it, if it's a top level
+// group. Groups of nested scopes are written as part of their top level groups.
+func (output *groupedOutput) finish(scopes []string, out io.Writer) {
+	group, ok := output.groups[strings.Join(scopes, "/")]
+	if !ok {
+		return
+	}
+	for i, topLevel := range output.topLevel {
+		if topLevel == group {
+			output.topLevel = append(output.topLevel[:i], output.topLevel[i+1:]...)
+			output.emit(group, out)
+			return
+		}
+	}
+}
+
+// discard releases the group of scope with path 'scopes' and its nested groups without writing them
+func (output *groupedOutput) discard(scopes []string) {
+	group, ok := output.groups[strings.Join(scopes, "/")]
+	if !ok {
+		return
+	}
+	output.detach(scopes)
+	for i, topLevel := range output.topLevel {
+		if topLevel == group {
+			output.topLevel = append(output.topLevel[:i], output.topLevel[i+1:]...)
+			break
+		}
+	}
+	output.release(group)
+}
+
+// detach moves the group of scope with path 'scopes' out of the group of its parent, so it
+// becomes a top level group.
+func (output *groupedOutput) detach(scopes []string) {
+	group, ok := output.groups[strings.Join(scopes, "/")]
+	if !ok || len(scopes) < 2 {
+		return
+	}
+	parent, ok := output.groups[strings.Join(scopes[:len(scopes)-1], "/")]
+	if !ok {
+		return
+	}
+	for i, segment := range parent.segments {
+		if segment.child == group {
+			parent.segments = append(parent.segments[:i], parent.segments[i+1:]...)
+			output.topLevel = append(output.topLevel, group)
+			return
+		}
+	}
+}
+
+// flush writes all top level groups to out and releases them
+func (output *groupedOutput) flush(out io.Writer) {
+	for _, group := range output.topLevel {
+		output.emit(group, out)
+	}
+	output.topLevel = nil
+}
+
+// emit writes group with all nested groups to out and releases them
+func (output *groupedOutput) emit(group *outputGroup, out io.Writer) {
+	output.writeGroup(group, out)
+	output.release(group)
+}
+
+// writeGroup writes group with all nested groups to out
+func (output *groupedOutput) writeGroup(group *outputGroup, out io.Writer) {
+	for _, segment := range group.segments {
+		if segment.child != nil {
+			output.writeGroup(segment.child, out)
+		} else {
+			_, _ = segment.text.WriteTo(out)
+		}
+	}
+}
+
+// release closes buffers of group and its nested groups, and forgets them
+func (output *groupedOutput) release(group *outputGroup) {
+	for _, segment := range group.segments {
+		if segment.child != nil {
+			output.release(segment.child)
+			continue
+		}
+		if !segment.text.Spilled() {
+			output.memory -= segment.text.Len()
+		}
+		_ = segment.text.Close()
+	}
+	group.segments = nil
+	if output.groups[group.path] == group {
+		delete(output.groups, group.path)
+	}
+}
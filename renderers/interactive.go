@@ -10,6 +10,8 @@ import (
 	"github.com/roberChen/echelon/renderers/config"
 	"github.com/roberChen/echelon/renderers/internal/console"
 	"github.com/roberChen/echelon/renderers/internal/node"
+	"github.com/roberChen/echelon/renderers/internal/runlog"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
 	"github.com/roberChen/echelon/terminal"
 )
 
@@ -30,9 +32,17 @@ type InteractiveRenderer struct {
 	rootNode          *node.EchelonNode
 	config            *config.InteractiveRendererConfig
 	currentFrameLines []string
-	drawLock          sync.Mutex
-	terminalHeight    int
-	terminalWidth     int
+	// failedNodes are the nodes which have failed, in finish order, guarded by drawLock
+	failedNodes []*node.EchelonNode
+	// logs writes messages of scopes to files under LogDirectory, it's nil if there's no LogDirectory
+	logs *runlog.Directory
+	// timeline records spans of all scopes for reports at the end of run
+	timeline *timeline.Recorder
+	// err is the first error of creating the run directory, its log files or its index, guarded by drawLock
+	err            error
+	drawLock       sync.Mutex
+	terminalHeight int
+	terminalWidth  int
 }
 
 // NewInteractiveRenderer creates a new InteractiveRenderer
@@ -40,13 +50,18 @@ func NewInteractiveRenderer(out *os.File, rendererConfig *config.InteractiveRend
 	if rendererConfig == nil {
 		rendererConfig = config.NewDefaultRenderingConfig()
 	}
-	return &InteractiveRenderer{
+	result := &InteractiveRenderer{
 		out:            bufio.NewWriterSize(out, defaultFrameBufSize),
 		rootNode:       node.NewEchelonNode("root", console.TerminalWidth(out), rendererConfig),
 		config:         rendererConfig,
 		terminalHeight: console.TerminalHeight(out),
 		terminalWidth:  console.TerminalWidth(out),
+		timeline:       timeline.NewRecorder(rendererConfig.FailureReportLines),
 	}
+	if rendererConfig.LogDirectory != "" {
+		result.logs, result.err = runlog.NewDirectory(rendererConfig.LogDirectory)
+	}
+	return result
 }
 
 // findScopeNode will return node with path 'scopes' in InteractiveRenderer, if the
@@ -61,35 +76,98 @@ func findScopedNode(scopes []string, r *InteractiveRenderer) *node.EchelonNode {
 
 // RenderScopeStarted starts render the node specified by the entry
 func (r *InteractiveRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
-	findScopedNode(entry.GetScopes(), r).Start(entry.GetProgressSize())
+	n := findScopedNode(entry.GetScopes(), r)
+	if n != r.rootNode && r.logs != nil {
+		path, err := r.logs.Start(entry.GetScopes())
+		r.setErr(err)
+		if path != "" {
+			n.SetLogPath(path)
+		}
+	}
+	n.Start(entry.GetProgressSize(), entry.GetOptions())
+	if n != r.rootNode {
+		r.timeline.StartAt(entry.GetScopes(), n.StartTime())
+	}
+	r.updateAggregates(entry.GetScopes())
 }
 
 // RenderScopeFinished will render an finished node specified by entry.
 //
-// If the node is succeeded, all sub nodes (which must be succeeded as well) will hides.
-// If the node is failed, the node will keep showing at output with FailureColor(red)
+// If the node is succeeded or skipped, all sub nodes (which must be succeeded as well) will hides.
+// If the node is failed, the node will keep showing at output with FailureColor(red), with the
+// cause of failure as its last line.
 func (r *InteractiveRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
 	n := findScopedNode(entry.GetScopes(), r)
+	if r.logs != nil {
+		if n == r.rootNode {
+			r.setErr(r.logs.Close())
+		} else {
+			_, err := r.logs.Finish(entry.GetScopes(), entry.Success())
+			r.setErr(err)
+		}
+	}
 	if entry.Success() {
 		if n != r.rootNode {
 			n.ClearAllChildren()
 			n.ClearDescription()
 		}
-		n.CompleteWithColor(r.config.SuccessStatus, r.config.Colors.SuccessColor)
+		if entry.Skipped() {
+			n.CompleteWithColor(r.config.SkippedStatus, r.config.Colors.NeutralColor)
+		} else {
+			n.CompleteWithColor(r.config.SuccessStatus, r.config.Colors.SuccessColor)
+		}
 		// succeed, set progress to full
 		if n.Pbar != nil {
 			n.Pbar.SetPercentage(100)
 		}
 	} else {
 		n.SetVisibleDescriptionLines(r.config.DescriptionLinesWhenFailed)
+		if entry.Cause() != nil {
+			n.AppendDescription(terminal.GetColoredText(r.config.Colors.FailureColor, entry.Cause().Error()) + "\n")
+		}
 		n.CompleteWithColor(r.config.FailureStatus, r.config.Colors.FailureColor)
+		r.drawLock.Lock()
+		r.failedNodes = append(r.failedNodes, n)
+		r.drawLock.Unlock()
+	}
+	r.timeline.FinishAt(entry, n.EndTime())
+	r.updateAggregates(entry.GetScopes())
+}
+
+// Err returns the first error of creating the run directory of LogDirectory, creating log files of
+// scopes or writing its index once the root has finished, log files aren't written if the directory
+// can't be created
+func (r *InteractiveRenderer) Err() error {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	return r.err
+}
+
+// setErr records err unless an error has been recorded
+func (r *InteractiveRenderer) setErr(err error) {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if r.err == nil {
+		r.err = err
 	}
 }
 
 // RenderMessage will render message of node specified by entry, it will add the messages of
-// entry to the node.
+// entry colored by its level to the node. Messages more verbose than the level of config are ignored
+// if it filters levels, but they're still written to the log file of scope. The last messages of root,
+// like notices about changed levels, are drawn above all scopes.
 func (r *InteractiveRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
-	findScopedNode(entry.GetScopes(), r).AppendDescription(entry.GetMessage() + "\n")
+	if r.logs != nil && len(entry.GetScopes()) > 0 {
+		r.logs.Write(entry.GetScopes(), entry.GetMessage())
+	}
+	if levelHidden(r.config.FilterLevel, r.config.Level, entry) {
+		return
+	}
+	message := decorateMessage(r.config.Colors, entry.Level, r.config.LevelBadges, entry.GetMessage())
+	findScopedNode(entry.GetScopes(), r).AppendDescription(message + "\n")
+	if len(entry.GetScopes()) > 0 {
+		r.timeline.AddLines(entry.GetScopes(), message)
+	}
 }
 
 // RenderProcess will set progress of node specified by entry
@@ -105,11 +183,20 @@ func (r *InteractiveRenderer) RenderProcess(entry *echelon.LogProcessMessage) {
 		node.Pbar.SetPercentage(entry.Percentage)
 	}
 	if entry.Progress != 0 {
-		node.Pbar.AddProgress(entry.Progress)
+		node.Pbar.SetProgress(entry.Progress)
 	}
 	if entry.Addprogress != 0 {
 		node.Pbar.AddProgress(entry.Addprogress)
 	}
+	r.updateAggregates(entry.GetScopes())
+}
+
+// updateAggregates recomputes progress of all ancestors of node with path 'scopes' which aggregate
+// progress of their children, from the deepest one to the root.
+func (r *InteractiveRenderer) updateAggregates(scopes []string) {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		findScopedNode(scopes[:i], r).UpdateAggregate()
+	}
 }
 
 // StartDrawing will start drawing Interactiverenderer until the root node has completed
@@ -126,10 +213,42 @@ func (r *InteractiveRenderer) StartDrawing() {
 }
 
 // StopDrawing will stop the InteractiveRenderer, it will complete the root node and draw final frame
+//
+// If OutputOnFailureOnly is configured, failed nodes show all their output in the final frame, which
+// is drawn in full even if it's higher than the terminal. If FailureReport is configured, the report
+// of failures and totals is printed below the final frame. Temp files of full output of scopes which
+// haven't finished are removed.
 func (r *InteractiveRenderer) StopDrawing() {
 	r.rootNode.Complete()
-	// one last redraw
-	r.DrawFrame()
+	if !r.config.OutputOnFailureOnly {
+		// one last redraw
+		r.DrawFrame()
+	} else {
+		r.drawLock.Lock()
+		for _, n := range r.failedNodes {
+			n.SetVisibleDescriptionLines(-1)
+		}
+		r.drawLock.Unlock()
+		r.drawFinalFrame()
+	}
+	r.writeSummaries()
+	r.rootNode.DiscardUnfinishedLogs()
+}
+
+// writeSummaries writes the reports configured to be printed at the end of run below the final frame
+func (r *InteractiveRenderer) writeSummaries() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	if r.config.SlowestScopes > 0 {
+		writeSlowestScopes(r.out, r.timeline, r.config.SlowestScopes)
+	}
+	if r.config.CriticalPath {
+		writeCriticalPath(r.out, r.timeline)
+	}
+	if r.config.FailureReport {
+		writeFailureReport(r.out, r.config.Colors, r.timeline)
+	}
+	_ = r.out.Flush()
 }
 
 // DrawFrame will first generate full output lines and put it to terminal.
@@ -138,10 +257,7 @@ func (r *InteractiveRenderer) StopDrawing() {
 func (r *InteractiveRenderer) DrawFrame() {
 	r.drawLock.Lock()
 	defer r.drawLock.Unlock()
-	var newFrameLines []string
-	for _, n := range r.rootNode.GetChildren() {
-		newFrameLines = append(newFrameLines, n.Render()...)
-	}
+	newFrameLines := r.frameLines()
 	if r.terminalHeight > 0 {
 		terminal.CalculateIncrementalUpdateMaxLines(r.out, r.currentFrameLines, newFrameLines, r.terminalHeight)
 	} else {
@@ -149,3 +265,30 @@ func (r *InteractiveRenderer) DrawFrame() {
 	}
 	r.currentFrameLines = newFrameLines
 }
+
+// frameLines returns the lines of a frame, the last messages of root are followed by all scopes
+func (r *InteractiveRenderer) frameLines() []string {
+	lines := r.rootNode.DescriptionLines()
+	for _, n := range r.rootNode.GetChildren() {
+		lines = append(lines, n.Render()...)
+	}
+	return lines
+}
+
+// drawFinalFrame erases the visible part of current frame and writes the whole new frame, without
+// limitation of terminal height. This function is coroutine safe.
+func (r *InteractiveRenderer) drawFinalFrame() {
+	r.drawLock.Lock()
+	defer r.drawLock.Unlock()
+	newFrameLines := r.frameLines()
+	if r.terminalHeight > 0 {
+		terminal.CalculateIncrementalUpdateMaxLines(r.out, r.currentFrameLines, nil, r.terminalHeight)
+	} else {
+		terminal.CalculateIncrementalUpdate(r.out, r.currentFrameLines, nil)
+	}
+	for _, line := range newFrameLines {
+		_, _ = r.out.WriteString(line + "\n")
+	}
+	_ = r.out.Flush()
+	r.currentFrameLines = newFrameLines
+}
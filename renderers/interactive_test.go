@@ -0,0 +1,144 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/roberChen/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+// renderInteractive renders entries sent by render with an InteractiveRenderer of rendererConfig,
+// and returns the renderer and its whole output once it has stopped drawing
+func renderInteractive(t *testing.T, rendererConfig *config.InteractiveRendererConfig, render func(r *InteractiveRenderer)) (*InteractiveRenderer, string) {
+	out, err := ioutil.TempFile("", "echelon-frame")
+	assert.NoError(t, err)
+	defer os.Remove(out.Name())
+	defer out.Close()
+	r := NewInteractiveRenderer(out, rendererConfig)
+	render(r)
+	r.StopDrawing()
+	content, err := ioutil.ReadFile(out.Name())
+	assert.NoError(t, err)
+	return r, string(content)
+}
+
+func Test_InteractiveRenderer_RenderProcess_SetProgress(t *testing.T) {
+	r := NewInteractiveRenderer(os.Stdout, nil)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(40, "download"))
+	for i := 0; i < 2; i++ {
+		progress := echelon.NewLogProcessMessage("download")
+		progress.Progress = 10
+		r.RenderProcess(progress)
+	}
+	bar := findScopedNode([]string{"download"}, r).Pbar
+	bar.SetDecorators([]echelon.BarDecorator{echelon.CountersDecorator})
+	assert.Contains(t, bar.String(40), "10/40")
+}
+
+func Test_InteractiveRenderer_OutputOnFailureOnly(t *testing.T) {
+	rendererConfig := config.NewDefaultRenderingConfig()
+	rendererConfig.OutputOnFailureOnly = true
+	rendererConfig.DescriptionLinesWhenFailed = 1
+	rendererConfig.DescriptionBufferLines = -1
+	_, output := renderInteractive(t, rendererConfig, func(r *InteractiveRenderer) {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "ok"))
+		r.RenderMessage(echelon.NewLogEntryMessage([]string{"ok"}, echelon.InfoLevel, "hidden output"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "ok"))
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "bad"))
+		for _, line := range []string{"first line", "second line", "third line"} {
+			r.RenderMessage(echelon.NewLogEntryMessage([]string{"bad"}, echelon.InfoLevel, line))
+		}
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "bad"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	})
+	assert.Contains(t, output, "ok")
+	assert.NotContains(t, output, "hidden output")
+	assert.Contains(t, output, "first line")
+	assert.Contains(t, output, "second line")
+	assert.Contains(t, output, "third line")
+}
+
+func Test_InteractiveRenderer_LogDirectory(t *testing.T) {
+	path, err := ioutil.TempDir("", "echelon-run")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	rendererConfig := config.NewDefaultRenderingConfig()
+	rendererConfig.LogDirectory = path
+	r, output := renderInteractive(t, rendererConfig, func(r *InteractiveRenderer) {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "test"))
+		r.RenderMessage(echelon.NewLogEntryMessage([]string{"test"}, echelon.InfoLevel, "details"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "test"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	})
+	logPath := filepath.Join(path, "test.log")
+	assert.Contains(t, output, "full log: "+logPath)
+	content, err := ioutil.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "details\n", string(content))
+	assert.FileExists(t, filepath.Join(path, "index.json"))
+	assert.NoError(t, r.Err())
+}
+
+func Test_InteractiveRenderer_LogDirectoryError(t *testing.T) {
+	file, err := ioutil.TempFile("", "echelon-run")
+	assert.NoError(t, err)
+	_ = file.Close()
+	defer os.Remove(file.Name())
+	rendererConfig := config.NewDefaultRenderingConfig()
+	rendererConfig.LogDirectory = filepath.Join(file.Name(), "logs")
+	r, output := renderInteractive(t, rendererConfig, func(r *InteractiveRenderer) {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "test"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "test"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	})
+	assert.NotContains(t, output, "full log:")
+	assert.Error(t, r.Err())
+}
+
+func Test_InteractiveRenderer_LogFileError(t *testing.T) {
+	path, err := ioutil.TempDir("", "echelon-run")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	assert.NoError(t, os.Mkdir(filepath.Join(path, "test.log"), 0o755))
+	rendererConfig := config.NewDefaultRenderingConfig()
+	rendererConfig.LogDirectory = path
+	r, output := renderInteractive(t, rendererConfig, func(r *InteractiveRenderer) {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "test"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "test"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	})
+	assert.NotContains(t, output, "full log:")
+	assert.Error(t, r.Err())
+}
+
+func Test_InteractiveRenderer_FailureReport(t *testing.T) {
+	rendererConfig := config.NewDefaultRenderingConfig()
+	rendererConfig.Colors = &terminal.ColorSchema{SuccessColor: -1, FailureColor: -1, NeutralColor: -1}
+	rendererConfig.FailureReport = true
+	rendererConfig.FailureReportLines = 2
+	_, output := renderInteractive(t, rendererConfig, func(r *InteractiveRenderer) {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build"))
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build", "test"))
+		for _, line := range []string{"one", "two", "three"} {
+			r.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "test"}, echelon.InfoLevel, line))
+		}
+		r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("exit status 1"), "build", "test"))
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "lint"))
+		r.RenderScopeFinished(echelon.NewLogScopeSkipped("cached", "lint"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "build"))
+		r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	})
+	output = withoutDurations(strings.ReplaceAll(output, terminal.ResetSequence, ""))
+	assert.True(t, strings.HasSuffix(output, "\nFailures:\n"+
+		"build failed in <d>\n"+
+		"build/test failed in <d>: exit status 1\n    two\n    three\n"+
+		"\n0 succeeded, 2 failed, 1 skipped in <d>\n"), output)
+}
@@ -0,0 +1,130 @@
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/utils"
+)
+
+// rateWindow is the time window of the moving average rate
+const rateWindow = 5 * time.Second
+
+// Decorator renders a piece of information of bar, it's called with the lock of bar held
+type Decorator func(b *Bar) string
+
+// rateSample is a progress record of bar at a certain time
+type rateSample struct {
+	at  time.Time
+	now int64
+}
+
+// NewDecorator returns the decorator of kind, it returns nil for an unknown kind
+func NewDecorator(kind echelon.BarDecorator) Decorator {
+	switch kind {
+	case echelon.PercentageDecorator:
+		return func(b *Bar) string {
+			return fmt.Sprintf("%3d%%", b.percentage)
+		}
+	case echelon.CountersDecorator:
+		return func(b *Bar) string {
+			return fmt.Sprintf("%d/%d", b.now, b.total)
+		}
+	case echelon.BytesDecorator, echelon.SIBytesDecorator:
+		si := kind == echelon.SIBytesDecorator
+		return func(b *Bar) string {
+			return utils.FormatBytes(b.now, si) + "/" + utils.FormatBytes(b.total, si)
+		}
+	case echelon.RateDecorator:
+		return func(b *Bar) string {
+			return fmt.Sprintf("%.1f/s", b.rate())
+		}
+	case echelon.BytesRateDecorator, echelon.SIBytesRateDecorator:
+		si := kind == echelon.SIBytesRateDecorator
+		return func(b *Bar) string {
+			return utils.FormatBytes(int64(b.rate()), si) + "/s"
+		}
+	case echelon.ETADecorator:
+		return func(b *Bar) string {
+			if b.IsFinished() {
+				return "ETA 0s"
+			}
+			rate := b.rate()
+			if rate <= 0 {
+				return "ETA --"
+			}
+			eta := time.Duration(float64(b.total-b.now) / rate * float64(time.Second))
+			return "ETA " + utils.FormatDuration(eta, false)
+		}
+	case echelon.ElapsedDecorator:
+		return func(b *Bar) string {
+			end := time.Now()
+			if !b.doneTime.IsZero() {
+				end = b.doneTime
+			}
+			return utils.FormatDuration(end.Sub(b.startTime), false)
+		}
+	}
+	return nil
+}
+
+// SetDecorators will set the decorators rendered after the bar, it's a coroutine safe function
+func (b *Bar) SetDecorators(kinds []echelon.BarDecorator) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decorators = make([]Decorator, 0, len(kinds))
+	for _, kind := range kinds {
+		if decorator := NewDecorator(kind); decorator != nil {
+			b.decorators = append(b.decorators, decorator)
+		}
+	}
+}
+
+// decorate renders all decorators separated by spaces, the lock must be held by caller
+func (b *Bar) decorate() string {
+	parts := make([]string, 0, len(b.decorators))
+	for _, decorator := range b.decorators {
+		parts = append(parts, decorator(b))
+	}
+	return strings.Join(parts, " ")
+}
+
+// progressed records the current progress for rate computing, the lock must be held by caller
+func (b *Bar) progressed() {
+	now := time.Now()
+	if b.IsFinished() && b.doneTime.IsZero() {
+		b.doneTime = now
+	}
+	b.samples = append(b.samples, rateSample{at: now, now: b.now})
+	b.dropOldSamples(now)
+}
+
+// dropOldSamples removes samples out of rate window, while keeping the latest one of them
+// as the start point of the window. The lock must be held by caller.
+func (b *Bar) dropOldSamples(now time.Time) {
+	old := 0
+	for old+1 < len(b.samples) && now.Sub(b.samples[old+1].at) > rateWindow {
+		old++
+	}
+	b.samples = b.samples[old:]
+}
+
+// rate returns the moving average of progress per second, the lock must be held by caller
+func (b *Bar) rate() float64 {
+	end := time.Now()
+	if !b.doneTime.IsZero() {
+		end = b.doneTime
+	}
+	first := rateSample{at: b.startTime}
+	if len(b.samples) > 0 && end.Sub(b.startTime) > rateWindow {
+		b.dropOldSamples(end)
+		first = b.samples[0]
+	}
+	elapsed := end.Sub(first.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(b.now-first.now) / elapsed
+}
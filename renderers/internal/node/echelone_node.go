@@ -2,11 +2,14 @@ package node
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/mattn/go-runewidth"
+	"github.com/roberChen/echelon"
 	"github.com/roberChen/echelon/renderers/config"
 	"github.com/roberChen/echelon/terminal"
 	"github.com/roberChen/echelon/utils"
@@ -15,6 +18,12 @@ import (
 
 const defaultVisibleLines = 5
 
+// descriptionLogPattern is the name pattern of temp files with full output of nodes
+const descriptionLogPattern = "echelon-*.log"
+
+// aggregateWeightScale is the total of bars aggregating children by weight
+const aggregateWeightScale = 1000
+
 // EchelonNode is a log node for interactive renderer, it is designed for coroutine safe object
 //
 // It has title with specific title color. it's max visible lines can be specified.
@@ -25,8 +34,20 @@ type EchelonNode struct {
 	status                  string
 	title                   string
 	titleColor              int
-	description             []string
+	// description keeps the last lines of output, the last line is the one being written
+	description             *lineRing
 	visibleDescriptionLines int
+	// logFile receives all complete lines of output if DescriptionSpill is configured, logPath is
+	// the path of full output which is shown when the node fails
+	logFile *os.File
+	logPath string
+	// logDiscarded tells the temp file of full output has been removed because the node is gone or the
+	// run has stopped, no new one is created
+	logDiscarded bool
+	// repeatKey is the key of the last complete line of description, and repeats is the number
+	// of times it has repeated
+	repeatKey string
+	repeats   int
 	config                  *config.InteractiveRendererConfig
 	startTime               time.Time
 	endTime                 time.Time
@@ -34,6 +55,10 @@ type EchelonNode struct {
 
 	// bar setting
 	Pbar *Bar
+	// aggregate tells how the bar is computed from children
+	aggregate echelon.AggregateMode
+	// weight is the explicit weight of node in the progress of parent
+	weight float64
 	// terminal width
 	width int
 }
@@ -41,7 +66,7 @@ type EchelonNode struct {
 // StartNewEchelonNode will create new EchelonNode with title and configuration, and start it.
 func StartNewEchelonNode(title string, width int, total int64, config *config.InteractiveRendererConfig) *EchelonNode {
 	result := NewEchelonNode(title, width, config)
-	result.Start(total)
+	result.Start(total, echelon.ScopeOptions{})
 	return result
 }
 
@@ -54,7 +79,7 @@ func NewEchelonNode(title string, width int, config *config.InteractiveRendererC
 		title:      title,
 		titleColor: config.Colors.NeutralColor,
 		// description is the texts will be diplayed to output
-		description:             make([]string, 0),
+		description:             newLineRing(descriptionCapacity(config)),
 		visibleDescriptionLines: defaultVisibleLines,
 		config:                  config,
 		startTime:               zeroTime,
@@ -88,25 +113,97 @@ func (node *EchelonNode) UpdateConfig(config *config.InteractiveRendererConfig)
 	node.config = config
 }
 
-// ClearAllChildren will remove all children nodes of current node, it's a coroutine
-// safe function
+// ClearAllChildren will remove all children nodes of current node, temp files of full output of
+// removed nodes are closed and removed. It's a coroutine safe function
 func (node *EchelonNode) ClearAllChildren() {
 	node.lock.Lock()
 	defer node.lock.Unlock()
+	for _, child := range node.children {
+		child.discardLogs(false)
+	}
 	node.children = make([]*EchelonNode, 0)
 }
 
+// DiscardUnfinishedLogs closes and removes temp files of full output of node and its descendants
+// which haven't completed, they're never shown since only completed nodes show their full log. It's
+// called once the run stops, and it's a coroutine safe function
+func (node *EchelonNode) DiscardUnfinishedLogs() {
+	node.discardLogs(true)
+}
+
+// discardLogs removes temp files of full output of node and its descendants, completed nodes are left
+// alone if unfinishedOnly is set. It locks node and its descendants.
+func (node *EchelonNode) discardLogs(unfinishedOnly bool) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	if !unfinishedOnly || node.endTime.IsZero() {
+		node.discardLog()
+		node.logDiscarded = true
+	}
+	for _, child := range node.children {
+		child.discardLogs(unfinishedOnly)
+	}
+}
+
+// discardLog closes and removes the temp file of full output and forgets its path, the path set by
+// SetLogPath is forgotten as well. The lock must be held by caller.
+func (node *EchelonNode) discardLog() {
+	if node.logFile != nil {
+		_ = node.logFile.Close()
+		_ = os.Remove(node.logFile.Name())
+		node.logFile = nil
+	}
+	node.logPath = ""
+}
+
+// descriptionCapacity returns the number of lines kept in description of nodes with config, which
+// includes the line being written.
+func descriptionCapacity(config *config.InteractiveRendererConfig) int {
+	lines := config.DescriptionBufferLines
+	if lines == 0 {
+		lines = config.DescriptionLinesWhenFailed
+		if lines < defaultVisibleLines {
+			lines = defaultVisibleLines
+		}
+	}
+	if lines < 0 {
+		return 0
+	}
+	return lines + 1
+}
+
 // ClearDescription will clean description of node, it will set description
-// as a empty string list. it's a coroutine safe function
+// as a empty string list. The temp file of full output is removed and the path
+// of full output is forgotten as well. it's a coroutine safe function
 func (node *EchelonNode) ClearDescription() {
 	node.SetDescription(make([]string, 0))
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	node.discardLog()
 }
 
 // SetDescription will set description of node, it's a coroutine safe function.
 func (node *EchelonNode) SetDescription(description []string) {
 	node.lock.Lock()
 	defer node.lock.Unlock()
-	node.description = description
+	node.description.reset(description)
+	node.repeats = 0
+}
+
+// SetLogPath sets the path of file with full output of node, which is shown when the node
+// fails. It's a coroutine safe function
+func (node *EchelonNode) SetLogPath(path string) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	node.logPath = path
+}
+
+// LogPath returns the path of file with full output of node, it's empty if there isn't one.
+// It's a coroutine safe function
+func (node *EchelonNode) LogPath() string {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	return node.logPath
 }
 
 // SetVisibleDescriptionLines will set max line number allowed to display for node.
@@ -121,7 +218,19 @@ func (node *EchelonNode) SetVisibleDescriptionLines(count int) {
 func (node *EchelonNode) DescriptionLength() int {
 	node.lock.RLock()
 	defer node.lock.RUnlock()
-	return len(node.description)
+	return node.description.Len()
+}
+
+// DescriptionLines returns the visible lines of description without title and children, the line
+// being written is left out while it's empty. It's a coroutine safe function
+func (node *EchelonNode) DescriptionLines() []string {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	lines := node.description.tail(node.visibleDescriptionLines)
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
 }
 
 // Render function will output the rendered text of a node, with it's sub nodes.
@@ -146,15 +255,15 @@ func (node *EchelonNode) render(indent int) []string {
 	defer node.lock.RUnlock()
 	// add indent for descriptions
 	sindent := strings.Repeat(" ", newindent)
-	if len(node.description) > node.visibleDescriptionLines && node.visibleDescriptionLines >= 0 {
+	lines := node.description.tail(node.visibleDescriptionLines)
+	if len(lines) < node.description.Len() || node.description.dropped > 0 {
 		result = append(result, sindent+"...")
-		for _, line := range node.description[(len(node.description) - node.visibleDescriptionLines):] {
-			result = append(result, sindent+line)
-		}
-	} else {
-		for _, line := range node.description {
-			result = append(result, sindent+line)
-		}
+	}
+	for _, line := range lines {
+		result = append(result, sindent+line)
+	}
+	if node.logPath != "" && !node.endTime.IsZero() {
+		result = append(result, sindent+"full log: "+node.logPath)
 	}
 
 	return result
@@ -216,6 +325,22 @@ func (node *EchelonNode) ExecutionDuration() time.Duration {
 	return node.endTime.Sub(node.startTime)
 }
 
+// StartTime returns the time node has started, it's zero if node hasn't started. It's a coroutine
+// safe function
+func (node *EchelonNode) StartTime() time.Time {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	return node.startTime
+}
+
+// EndTime returns the time node has completed, it's zero if node hasn't completed. It's a coroutine
+// safe function
+func (node *EchelonNode) EndTime() time.Time {
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	return node.endTime
+}
+
 // HasStarted returns wheter a node has started, a finished node is also started. This
 // is a coroutine safe function
 func (node *EchelonNode) HasStarted() bool {
@@ -269,15 +394,21 @@ func (node *EchelonNode) AddNewChild(child *EchelonNode) {
 	node.children = append(node.children, child)
 }
 
-// Start will start node, it sets the start time. It's a coroutine safe function
-func (node *EchelonNode) Start(total int64) {
+// Start will start node, it sets the start time. If total is not 0, a progress bar set up
+// with options is created. It's a coroutine safe function
+func (node *EchelonNode) Start(total int64, options echelon.ScopeOptions) {
 	node.lock.Lock()
 	defer node.lock.Unlock()
 	if node.startTime.IsZero() {
 		node.startTime = time.Now()
 	}
-	if total != 0 {
-		node.Pbar = NewBar(total, nil )
+	node.aggregate = options.Aggregate
+	node.weight = options.Weight
+	if node.aggregate == echelon.AggregateByWeight {
+		total = aggregateWeightScale
+	}
+	if total != 0 || node.aggregate != echelon.NoAggregate {
+		node.Pbar = newConfiguredBar(total, options, node.config)
 	}
 }
 
@@ -293,6 +424,7 @@ func (node *EchelonNode) CompleteWithColor(status string, titleColor int) {
 	if node.startTime.IsZero() {
 		node.startTime = node.endTime
 	}
+	node.closeLog()
 	node.status = status
 	node.titleColor = titleColor
 	node.done.Done()
@@ -309,6 +441,7 @@ func (node *EchelonNode) Complete() {
 	if node.startTime.IsZero() {
 		node.startTime = node.endTime
 	}
+	node.closeLog()
 	node.done.Done()
 }
 
@@ -333,6 +466,9 @@ func (node *EchelonNode) WaitCompletion() {
 
 // AppendDescription will add text which might be multilines to node description, it
 // won't start new line at the end of description. It's a coroutine safe function.
+//
+// If CollapseRepeatedMessages is configured, a complete line repeating the line before it
+// replaces that line with a counter, like "waiting for lock... (x42)".
 func (node *EchelonNode) AppendDescription(text string) {
 	if node.HasCompleted() {
 		return
@@ -340,16 +476,125 @@ func (node *EchelonNode) AppendDescription(text string) {
 	node.lock.Lock()
 	defer node.lock.Unlock()
 	linesToAppend := strings.Split(text, "\n")
-	if len(linesToAppend) == 0 {
+	if node.description.Len() == 0 {
+		node.description.push("")
+	}
+	// append first new line to the last one
+	last := node.description.Len() - 1
+	node.description.set(last, node.description.at(last)+linesToAppend[0])
+	for _, line := range linesToAppend[1:] {
+		node.logLine(node.description.at(node.description.Len() - 1))
+		node.completeLastLine()
+		node.description.push(line)
+	}
+}
+
+// logLine writes a complete line of output to the temp file of full output if DescriptionSpill is
+// configured, the file is created by the first line unless the full output is kept at the path set by
+// SetLogPath. The lock must be held by caller.
+func (node *EchelonNode) logLine(line string) {
+	if !node.config.DescriptionSpill || node.logDiscarded || (node.logFile == nil && node.logPath != "") {
 		return
 	}
-	if len(node.description) == 0 {
-		node.description = linesToAppend
+	if node.logFile == nil {
+		file, err := ioutil.TempFile(node.config.DescriptionSpillDirectory, descriptionLogPattern)
+		if err != nil {
+			return
+		}
+		node.logFile = file
+		node.logPath = file.Name()
+	}
+	_, _ = node.logFile.WriteString(line + "\n")
+}
+
+// closeLog writes the line being written to the temp file of full output and closes the file,
+// the file is kept so it can be read once the node fails. The lock must be held by caller.
+func (node *EchelonNode) closeLog() {
+	if node.logFile == nil {
 		return
 	}
-	// append first new line to the last one
-	node.description[len(node.description)-1] = node.description[len(node.description)-1] + linesToAppend[0]
-	if len(linesToAppend) > 1 {
-		node.description = append(node.description, linesToAppend[1:]...)
+	if last := node.description.Len() - 1; last >= 0 && node.description.at(last) != "" {
+		_, _ = node.logFile.WriteString(node.description.at(last))
+	}
+	_ = node.logFile.Close()
+}
+
+// completeLastLine collapses the last line of description which has just completed into the line
+// before it if it repeats. The lock must be held by caller.
+func (node *EchelonNode) completeLastLine() {
+	mode := node.config.CollapseRepeatedMessages
+	if mode == config.NoCollapse {
+		return
+	}
+	last := node.description.Len() - 1
+	line := node.description.at(last)
+	key := mode.RepeatKey(line)
+	if last > 0 && node.repeats > 0 && key == node.repeatKey {
+		node.repeats++
+		node.description.set(last-1, fmt.Sprintf("%s (x%d)", line, node.repeats))
+		node.description.pop()
+		return
+	}
+	node.repeatKey = key
+	node.repeats = 1
+}
+
+// UpdateAggregate will recompute the progress bar of node from its children if the node
+// aggregates their progress. It's a coroutine safe function
+func (node *EchelonNode) UpdateAggregate() {
+	if node.HasCompleted() {
+		return
+	}
+	node.lock.RLock()
+	mode := node.aggregate
+	bar := node.Pbar
+	children := node.children
+	node.lock.RUnlock()
+	if bar == nil {
+		return
+	}
+	switch mode {
+	case echelon.AggregateByCount:
+		completed := 0
+		for _, child := range children {
+			if child.HasCompleted() {
+				completed++
+			}
+		}
+		bar.SetTotal(int64(len(children)))
+		bar.SetProgress(int64(completed))
+	case echelon.AggregateByWeight:
+		var done, weights float64
+		for _, child := range children {
+			weight, fraction := child.weightedProgress()
+			done += weight * fraction
+			weights += weight
+		}
+		if weights > 0 {
+			bar.SetProgress(int64(done / weights * aggregateWeightScale))
+		}
+	case echelon.NoAggregate:
+	}
+}
+
+// weightedProgress returns the weight of node in the progress of parent and its finished fraction
+func (node *EchelonNode) weightedProgress() (float64, float64) {
+	completed := node.HasCompleted()
+	node.lock.RLock()
+	defer node.lock.RUnlock()
+	weight := node.weight
+	if weight <= 0 {
+		weight = 1
+		if node.Pbar != nil && node.aggregate == echelon.NoAggregate {
+			weight = float64(node.Pbar.Total())
+		}
+	}
+	switch {
+	case completed:
+		return weight, 1
+	case node.Pbar != nil:
+		return weight, node.Pbar.Fraction()
+	default:
+		return weight, 0
 	}
 }
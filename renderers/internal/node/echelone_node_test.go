@@ -0,0 +1,150 @@
+//nolint:testpackage
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UpdateAggregate_ByCount(t *testing.T) {
+	parent := NewEchelonNode("parent", 80, config.NewDefaultUnixRenderingConfig())
+	parent.Start(echelon.NoProgress, echelon.NewScopeOptions(echelon.WithAggregate(echelon.AggregateByCount)))
+	for _, title := range []string{"one", "two", "three", "four"} {
+		parent.FindOrCreateChild(title).Start(echelon.NoProgress, echelon.ScopeOptions{})
+	}
+	parent.FindOrCreateChild("two").Complete()
+	parent.UpdateAggregate()
+	assert.Equal(t, int64(4), parent.Pbar.Total())
+	assert.InDelta(t, 0.25, parent.Pbar.Fraction(), 0.001)
+}
+
+func Test_UpdateAggregate_ByWeight(t *testing.T) {
+	parent := NewEchelonNode("parent", 80, config.NewDefaultUnixRenderingConfig())
+	parent.Start(echelon.NoProgress, echelon.NewScopeOptions(echelon.WithAggregate(echelon.AggregateByWeight)))
+	big := parent.FindOrCreateChild("big")
+	big.Start(300, echelon.ScopeOptions{})
+	big.Pbar.SetProgress(150)
+	explicit := parent.FindOrCreateChild("explicit")
+	explicit.Start(echelon.NoProgress, echelon.NewScopeOptions(echelon.WithWeight(100)))
+	explicit.Complete()
+	parent.UpdateAggregate()
+	// (300 * 0.5 + 100 * 1) / 400
+	assert.InDelta(t, 0.625, parent.Pbar.Fraction(), 0.001)
+}
+
+func Test_UpdateAggregate_ByWeight_Totals(t *testing.T) {
+	parent := NewEchelonNode("parent", 80, config.NewDefaultUnixRenderingConfig())
+	parent.Start(echelon.NoProgress, echelon.NewScopeOptions(echelon.WithAggregate(echelon.AggregateByWeight)))
+	big := parent.FindOrCreateChild("big")
+	big.Start(300, echelon.ScopeOptions{})
+	small := parent.FindOrCreateChild("small")
+	small.Start(1, echelon.ScopeOptions{})
+	small.Pbar.SetProgress(1)
+	parent.UpdateAggregate()
+	// (300 * 0 + 1 * 1) / 301
+	assert.InDelta(t, 1.0/301, parent.Pbar.Fraction(), 0.001)
+}
+
+func Test_Bar_AddProgress_Aggregate(t *testing.T) {
+	parent := NewEchelonNode("parent", 80, config.NewDefaultUnixRenderingConfig())
+	parent.Start(echelon.NoProgress, echelon.NewScopeOptions(echelon.WithAggregate(echelon.AggregateByCount)))
+	assert.NotPanics(t, func() {
+		parent.Pbar.AddProgress(1)
+	})
+	assert.Equal(t, int64(0), parent.Pbar.Total())
+	assert.InDelta(t, 0, parent.Pbar.Fraction(), 0.001)
+}
+
+func Test_DescriptionLines(t *testing.T) {
+	root := NewEchelonNode("root", 80, config.NewDefaultUnixRenderingConfig())
+	assert.Empty(t, root.DescriptionLines())
+	root.AppendDescription("log level changed to debug\n")
+	root.AppendDescription("partial")
+	assert.Equal(t, []string{"log level changed to debug", "partial"}, root.DescriptionLines())
+	root.AppendDescription("\n")
+	assert.Equal(t, []string{"log level changed to debug", "partial"}, root.DescriptionLines())
+}
+
+func Test_AppendDescription_CollapseRepeatedMessages(t *testing.T) {
+	rendererConfig := config.NewDefaultUnixRenderingConfig()
+	rendererConfig.CollapseRepeatedMessages = config.CollapseIdentical
+	node := NewEchelonNode("node", 80, rendererConfig)
+	node.AppendDescription("start\n")
+	for i := 0; i < 3; i++ {
+		node.AppendDescription("waiting for lock...\n")
+	}
+	node.AppendDescription("done\n")
+	assert.Equal(t, []string{"start", "waiting for lock... (x3)", "done", ""}, node.description.tail(-1))
+}
+
+func Test_AppendDescription_BoundedBuffer(t *testing.T) {
+	rendererConfig := config.NewDefaultUnixRenderingConfig()
+	rendererConfig.DescriptionBufferLines = 3
+	rendererConfig.DescriptionSpill = true
+	node := NewEchelonNode("node", 80, rendererConfig)
+	node.SetVisibleDescriptionLines(-1)
+	node.Start(echelon.NoProgress, echelon.ScopeOptions{})
+	node.AppendDescription("one\ntwo\nthree\nfour\nfive\npartial")
+	assert.Equal(t, []string{"three", "four", "five", "partial"}, node.description.tail(-1))
+	node.CompleteWithColor(rendererConfig.FailureStatus, rendererConfig.Colors.FailureColor)
+	path := node.LogPath()
+	defer os.Remove(path)
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "one\ntwo\nthree\nfour\nfive\npartial", string(content))
+	assert.Equal(t, []string{"   ...", "   three", "   four", "   five", "   partial", "   full log: " + path}, node.Render()[1:])
+}
+
+func Test_ClearDescription_RemovesLog(t *testing.T) {
+	rendererConfig := config.NewDefaultUnixRenderingConfig()
+	rendererConfig.DescriptionSpill = true
+	node := NewEchelonNode("node", 80, rendererConfig)
+	node.AppendDescription("line\n")
+	path := node.LogPath()
+	node.ClearDescription()
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "", node.LogPath())
+}
+
+func Test_ClearAllChildren_RemovesLogs(t *testing.T) {
+	rendererConfig := config.NewDefaultUnixRenderingConfig()
+	rendererConfig.DescriptionSpill = true
+	parent := StartNewEchelonNode("parent", 80, 0, rendererConfig)
+	child := parent.StartNewChild("child")
+	nested := child.StartNewChild("nested")
+	child.AppendDescription("failed\n")
+	nested.AppendDescription("running\n")
+	child.Complete()
+	paths := []string{child.LogPath(), nested.LogPath()}
+	parent.ClearAllChildren()
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	}
+	nested.AppendDescription("more\n")
+	assert.Equal(t, "", nested.LogPath())
+}
+
+func Test_DiscardUnfinishedLogs(t *testing.T) {
+	rendererConfig := config.NewDefaultUnixRenderingConfig()
+	rendererConfig.DescriptionSpill = true
+	root := StartNewEchelonNode("root", 80, 0, rendererConfig)
+	failed := root.StartNewChild("failed")
+	running := root.StartNewChild("running")
+	failed.AppendDescription("failed\n")
+	running.AppendDescription("running\n")
+	failed.Complete()
+	defer os.Remove(failed.LogPath())
+	path := running.LogPath()
+	root.DiscardUnfinishedLogs()
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "", running.LogPath())
+	assert.FileExists(t, failed.LogPath())
+}
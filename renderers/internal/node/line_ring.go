@@ -0,0 +1,89 @@
+package node
+
+// lineRing is a ring buffer of the last lines of a description, once it's full, pushing a line drops
+// the oldest one. A ring without capacity keeps all lines. It isn't coroutine safe.
+type lineRing struct {
+	lines    []string
+	capacity int
+	// head is the index of the oldest line in lines
+	head int
+	// dropped is the number of lines dropped since the last reset
+	dropped int
+}
+
+// newLineRing creates a ring buffer keeping the last capacity lines, capacity less than 1 means there
+// is no limitation
+func newLineRing(capacity int) *lineRing {
+	return &lineRing{capacity: capacity}
+}
+
+// Len returns the number of lines in ring
+func (ring *lineRing) Len() int {
+	return len(ring.lines)
+}
+
+// index returns the index in lines of the i-th oldest line
+func (ring *lineRing) index(i int) int {
+	return (ring.head + i) % len(ring.lines)
+}
+
+// at returns the i-th oldest line
+func (ring *lineRing) at(i int) string {
+	return ring.lines[ring.index(i)]
+}
+
+// set replaces the i-th oldest line
+func (ring *lineRing) set(i int, line string) {
+	ring.lines[ring.index(i)] = line
+}
+
+// push appends line as the newest line, the oldest line is dropped if the ring is full
+func (ring *lineRing) push(line string) {
+	if ring.capacity < 1 || len(ring.lines) < ring.capacity {
+		// lines are in order unless the ring has been full, in which case it stays full
+		ring.lines = append(ring.lines, line)
+		return
+	}
+	ring.lines[ring.head] = line
+	ring.head = (ring.head + 1) % len(ring.lines)
+	ring.dropped++
+}
+
+// pop removes the newest line
+func (ring *lineRing) pop() {
+	ring.linearize()
+	ring.lines = ring.lines[:len(ring.lines)-1]
+}
+
+// linearize moves lines in order, so that the oldest line is the first one
+func (ring *lineRing) linearize() {
+	if ring.head == 0 {
+		return
+	}
+	lines := make([]string, 0, len(ring.lines))
+	lines = append(lines, ring.lines[ring.head:]...)
+	ring.lines = append(lines, ring.lines[:ring.head]...)
+	ring.head = 0
+}
+
+// tail returns the newest count lines in order, negative count returns all lines
+func (ring *lineRing) tail(count int) []string {
+	if count < 0 || count > len(ring.lines) {
+		count = len(ring.lines)
+	}
+	result := make([]string, count)
+	for i := range result {
+		result[i] = ring.at(len(ring.lines) - count + i)
+	}
+	return result
+}
+
+// reset replaces all lines of ring by lines, only the newest ones are kept if there are too many
+func (ring *lineRing) reset(lines []string) {
+	ring.lines = nil
+	ring.head = 0
+	ring.dropped = 0
+	for _, line := range lines {
+		ring.push(line)
+	}
+}
@@ -0,0 +1,34 @@
+//nolint:testpackage
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_lineRing(t *testing.T) {
+	ring := newLineRing(3)
+	for _, line := range []string{"a", "b", "c", "d"} {
+		ring.push(line)
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, ring.tail(-1))
+	assert.Equal(t, []string{"c", "d"}, ring.tail(2))
+	assert.Equal(t, 1, ring.dropped)
+	ring.set(2, "D")
+	ring.pop()
+	ring.push("e")
+	assert.Equal(t, []string{"b", "c", "e"}, ring.tail(-1))
+	ring.reset([]string{"x"})
+	assert.Equal(t, []string{"x"}, ring.tail(5))
+	assert.Equal(t, 0, ring.dropped)
+}
+
+func Test_lineRing_Unbounded(t *testing.T) {
+	ring := newLineRing(0)
+	for i := 0; i < 100; i++ {
+		ring.push("line")
+	}
+	assert.Equal(t, 100, ring.Len())
+	assert.Equal(t, 0, ring.dropped)
+}
@@ -4,17 +4,29 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/mattn/go-runewidth"
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
 	"github.com/roberChen/echelon/terminal"
 )
 
 const (
 	// DefaultStyle is the default bar style
-	DefaultStyle = "╢▌▌░╟"
+	DefaultStyle = config.DefaultBarStyle
 	// SimpleStyle is simple style of bar, similar to the bar of wget
-	SimpleStyle = "[=>-]"
+	SimpleStyle = config.ASCIIBarStyle
 )
 
+// subCellRunes are the Unicode eighth blocks from one eighth to a full cell, they draw fractions of
+// cells for styles which don't have their own sub-cell runes
+var subCellRunes = []rune("▏▎▍▌▋▊▉█") //nolint:gochecknoglobals
+
+// styleRunes is the number of runes of a style without sub-cell runes
+const styleRunes = 5
+
 // Bar is a structure which defines the horizontal progress bar
 type Bar struct {
 	/*
@@ -40,14 +52,63 @@ type Bar struct {
 	now int64
 	// percentage of progress, 0-100
 	percentage int
+
+	// decorators are rendered after the bar
+	decorators []Decorator
+	// startTime is the creation time of bar, and doneTime is the time bar has finished
+	startTime time.Time
+	doneTime  time.Time
+	// samples are the recent progress records used for computing moving average rate
+	samples []rateSample
+
+	// colors of bar, nil for uncolored bar
+	colors *echelon.BarColors
+	// subCell tells whether to draw fractions of a cell with subCells
+	subCell bool
+	// subCells are the runes of fractions of a cell from the smallest one to a full cell, there are
+	// none for ASCII styles whose bars are drawn in whole cells
+	subCells []rune
 }
 
 // SetProgress set the progress of bar, it's a coroutine safe function
 func (b *Bar) SetProgress(i int64) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	if i > b.total {
+		i = b.total
+	}
 	b.now = i
-	b.percentage = int(100 * i / b.total)
+	b.percentage = 0
+	if b.total > 0 {
+		b.percentage = int(100 * i / b.total)
+	}
+	b.progressed()
+}
+
+// SetTotal set the total size of bar and keeps the current progress, it's a coroutine safe function
+func (b *Bar) SetTotal(total int64) {
+	b.mu.Lock()
+	b.total = total
+	now := b.now
+	b.mu.Unlock()
+	b.SetProgress(now)
+}
+
+// Fraction returns the finished fraction of bar, from 0 to 1. It's a coroutine safe function
+func (b *Bar) Fraction() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.total <= 0 {
+		return float64(b.percentage) / 100
+	}
+	return float64(b.now) / float64(b.total)
+}
+
+// Total returns the total size of bar, it's a coroutine safe function
+func (b *Bar) Total() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total
 }
 
 // AddProgress add the progress of bar, it's a coroutine safe function
@@ -58,7 +119,11 @@ func (b *Bar) AddProgress(i int64) {
 	if b.now > b.total {
 		b.now = b.total
 	}
-	b.percentage = int(100 * b.now / b.total)
+	b.percentage = 0
+	if b.total > 0 {
+		b.percentage = int(100 * b.now / b.total)
+	}
+	b.progressed()
 }
 
 // IsFinished returns whether bar has done
@@ -75,7 +140,8 @@ func (b *Bar) SetPercentage(i int) {
 	} else {
 		b.percentage = i
 	}
-	b.now = b.total * int64(b.percentage)
+	b.now = b.total * int64(b.percentage) / 100
+	b.progressed()
 }
 
 // AddPercentage adds percentage for bar, it's a coroutine safe function
@@ -86,33 +152,108 @@ func (b *Bar) AddPercentage(i int) {
 	if b.percentage > 100 {
 		b.percentage = 100
 	}
-	b.now = b.total * int64(b.percentage)
+	b.now = b.total * int64(b.percentage) / 100
+	b.progressed()
 }
 
-// String returns the render of bar
+// String returns the render of bar, the decorators are rendered after the bar.
 func (b *Bar) String(width int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	decorations := b.decorate()
+	if decorations != "" {
+		decorations = " " + decorations
+		width -= runewidth.StringWidth(decorations)
+	}
 	if width <= 2 {
 		return ""
 	}
 	width -= 2
-	finished := int(width * b.percentage / 100)
-	remains := width - 1 - finished
-	// finished, no tip needed
-	if finished == width {
-		remains++
-	}
-	if finished <0 || remains < 0 {
-		fmt.Printf("ERROR: negative repeat: finished:%d\tremains:%d\n", finished, remains)
-	}
-	var out string
-	if !b.IsFinished() {
-		out = fmt.Sprintf("%c%s%c%s%c", b.lbound, strings.Repeat(string(b.fill), finished),
-			b.tip, strings.Repeat(string(b.space), remains), b.rbound)
+	if b.IsFinished() {
+		return terminal.GetColoredText(terminal.GreenColor, " Done") + decorations
+	}
+	var filled, empty string
+	if b.subCell && len(b.subCells) > 0 {
+		filled, empty = b.subCellParts(width)
 	} else {
-		out =  terminal.GetColoredText(terminal.GreenColor," Done")
+		finished := width * b.percentage / 100
+		filled = strings.Repeat(string(b.fill), finished) + string(b.tip)
+		empty = strings.Repeat(string(b.space), width-1-finished)
 	}
+	return string(b.lbound) + b.colorize(b.filledColor(), filled) +
+		b.colorize(b.emptyColor(), empty) + string(b.rbound) + decorations
+}
 
-	return out
+// subCellParts returns the finished and unfinished parts of bar with width cells, the
+// finished part ends with a sub-cell rune showing the fraction of the last cell.
+func (b *Bar) subCellParts(width int) (string, string) {
+	fraction := float64(b.percentage) / 100
+	if b.total > 0 {
+		fraction = float64(b.now) / float64(b.total)
+	}
+	steps := len(b.subCells)
+	parts := int(fraction * float64(width*steps))
+	full := parts / steps
+	filled := strings.Repeat(string(b.subCells[steps-1]), full)
+	if part := parts % steps; part > 0 {
+		filled += string(b.subCells[part-1])
+		full++
+	}
+	return filled, strings.Repeat(string(b.space), width-full)
+}
+
+// filledColor returns color of the finished part, it picks the gradient color by percentage
+func (b *Bar) filledColor() int {
+	if b.colors == nil {
+		return -1
+	}
+	if len(b.colors.Gradient) == 0 {
+		return colorOf(b.colors.Filled)
+	}
+	index := b.percentage * len(b.colors.Gradient) / 100
+	if index >= len(b.colors.Gradient) {
+		index = len(b.colors.Gradient) - 1
+	}
+	return b.colors.Gradient[index]
+}
+
+// emptyColor returns color of the unfinished part
+func (b *Bar) emptyColor() int {
+	if b.colors == nil {
+		return -1
+	}
+	return colorOf(b.colors.Empty)
+}
+
+// colorOf returns color, or -1 which leaves text uncolored if color is unset
+func colorOf(color *int) int {
+	if color == nil {
+		return -1
+	}
+	return *color
+}
+
+// colorize colors text, negative color or empty text stays uncolored
+func (b *Bar) colorize(color int, text string) string {
+	if color < 0 || text == "" {
+		return text
+	}
+	return terminal.GetColoredText(color, text)
+}
+
+// SetColors will set the colors of bar, nil for uncolored bar. It's a coroutine safe function
+func (b *Bar) SetColors(colors *echelon.BarColors) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.colors = colors
+}
+
+// SetSubCellPrecision will set whether to draw fractions of a cell with the sub-cell runes of style,
+// it's a coroutine safe function
+func (b *Bar) SetSubCellPrecision(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subCell = enabled
 }
 
 // SetStyle will set the style of bar, it's a coroutine safe function
@@ -122,7 +263,7 @@ func (b *Bar) SetStyle(style []rune) error {
 	if style == nil {
 		style = []rune(DefaultStyle)
 	}
-	if len(style) != 5 {
+	if len(style) < styleRunes {
 		return fmt.Errorf("Invalid style")
 	}
 	b.lbound = style[0]
@@ -130,20 +271,62 @@ func (b *Bar) SetStyle(style []rune) error {
 	b.tip = style[2]
 	b.space = style[3]
 	b.rbound = style[4]
+	b.subCells = styleSubCells(style)
+	return nil
+}
+
+// styleSubCells returns the sub-cell runes of style, which are the runes after the first five ones.
+// Styles without them take the eighth blocks, unless they're made of ASCII characters only.
+func styleSubCells(style []rune) []rune {
+	if len(style) > styleRunes {
+		return style[styleRunes:]
+	}
+	for _, r := range style {
+		if r >= utf8.RuneSelf {
+			return subCellRunes
+		}
+	}
 	return nil
 }
 
-// NewBar creates new bar, the style must have five utf8 char
+// NewBar creates new bar, the style must have five utf8 char optionally followed by sub-cell runes
 func NewBar(total int64, style []rune) *Bar {
-	if style == nil || len(style) != 5 {
+	if len(style) < styleRunes {
 		style = []rune(DefaultStyle)
 	}
 	return &Bar{
-		lbound: style[0],
-		fill:   style[1],
-		tip:    style[2],
-		space:  style[3],
-		rbound: style[4],
-		total:  total,
+		lbound:    style[0],
+		fill:      style[1],
+		tip:       style[2],
+		space:     style[3],
+		rbound:    style[4],
+		subCells:  styleSubCells(style),
+		total:     total,
+		startTime: time.Now(),
+	}
+}
+
+// newConfiguredBar creates a bar set up by options of scope, with fallback to settings of renderer config
+func newConfiguredBar(total int64, options echelon.ScopeOptions, config *config.InteractiveRendererConfig) *Bar {
+	style := options.BarStyle
+	if style == "" {
+		style = config.BarStyle
+	}
+	bar := NewBar(total, []rune(style))
+	decorators := options.Decorators
+	if decorators == nil {
+		decorators = config.BarDecorators
+	}
+	bar.SetDecorators(decorators)
+	colors := options.BarColors
+	if colors == nil {
+		colors = config.BarColors
+	}
+	bar.SetColors(colors)
+	subCell := config.BarSubCellPrecision
+	if options.SubCellPrecision != nil {
+		subCell = *options.SubCellPrecision
 	}
+	bar.SetSubCellPrecision(subCell)
+	return bar
 }
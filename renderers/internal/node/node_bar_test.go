@@ -0,0 +1,59 @@
+//nolint:testpackage
+package node
+
+import (
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/terminal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bar_Decorators(t *testing.T) {
+	bar := NewBar(40, []rune(SimpleStyle))
+	bar.SetDecorators([]echelon.BarDecorator{echelon.PercentageDecorator, echelon.CountersDecorator})
+	bar.SetProgress(10)
+	assert.Equal(t, "[====>-------------]  25% 10/40", bar.String(31))
+}
+
+func Test_Bar_SetPercentage(t *testing.T) {
+	bar := NewBar(2048, nil)
+	bar.SetDecorators([]echelon.BarDecorator{echelon.BytesDecorator})
+	bar.SetPercentage(50)
+	assert.Equal(t, "1.0 KiB/2.0 KiB", bar.decorate())
+}
+
+func Test_Bar_AddPercentage(t *testing.T) {
+	bar := NewBar(200, nil)
+	bar.SetDecorators([]echelon.BarDecorator{echelon.CountersDecorator})
+	bar.AddPercentage(25)
+	bar.AddPercentage(25)
+	assert.Equal(t, "100/200", bar.decorate())
+}
+
+func Test_Bar_SubCellPrecision(t *testing.T) {
+	bar := NewBar(80, []rune(DefaultStyle))
+	bar.SetSubCellPrecision(true)
+	bar.SetProgress(13)
+	assert.Equal(t, "╢█▋░░░░░░░░╟", bar.String(12))
+}
+
+func Test_Bar_SubCellPrecision_Style(t *testing.T) {
+	bar := NewBar(90, []rune(SimpleStyle+".:="))
+	bar.SetSubCellPrecision(true)
+	bar.SetProgress(17)
+	assert.Equal(t, "[=:-------]", bar.String(11))
+	ascii := NewBar(80, []rune(SimpleStyle))
+	ascii.SetSubCellPrecision(true)
+	ascii.SetProgress(13)
+	assert.Equal(t, "[=>--------]", ascii.String(12))
+}
+
+func Test_Bar_GradientColors(t *testing.T) {
+	bar := NewBar(100, []rune(SimpleStyle))
+	bar.SetColors(&echelon.BarColors{Gradient: []int{1, 3, 2}})
+	bar.SetProgress(50)
+	assert.Equal(t, "[\033[33m===>\033[0m--]", bar.String(8))
+	bar.SetColors(&echelon.BarColors{Filled: echelon.BarColor(terminal.GreenColor), Empty: echelon.BarColor(0)})
+	assert.Equal(t, "[\033[32m===>\033[0m\033[30m--\033[0m]", bar.String(8))
+}
@@ -0,0 +1,199 @@
+package runlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndexFileName is the name of index file in run directories
+const IndexFileName = "index.json"
+
+// Outcomes of scopes in index
+const (
+	OutcomeRunning   = "running"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// IndexEntry is the record of a scope in index file
+type IndexEntry struct {
+	Scope    string    `json:"scope"`
+	File     string    `json:"file"`
+	Outcome  string    `json:"outcome"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+}
+
+// scopeLog is the log file of a scope with its index entry
+type scopeLog struct {
+	file  *os.File
+	entry *IndexEntry
+}
+
+// Directory writes the complete message stream of every scope to its own file under a run directory,
+// and an index file mapping scopes to their files and outcomes once it's closed. It's coroutine safe.
+type Directory struct {
+	lock  sync.Mutex
+	path  string
+	logs  map[string]*scopeLog
+	index []*IndexEntry
+	// used are the relative paths of created files, for resolving collisions of sanitized paths
+	used map[string]bool
+}
+
+// NewDirectory creates the run directory at path if it doesn't exist
+func NewDirectory(path string) (*Directory, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, err
+	}
+	return &Directory{
+		path: path,
+		logs: make(map[string]*scopeLog),
+		used: make(map[string]bool),
+	}, nil
+}
+
+// Start creates the log file of scope with path 'scopes' and returns its path, the path of the
+// existing file is returned if the scope has started. The path is empty if the file can't be created.
+func (directory *Directory) Start(scopes []string) (string, error) {
+	directory.lock.Lock()
+	defer directory.lock.Unlock()
+	log, err := directory.start(scopes)
+	if err != nil {
+		return "", err
+	}
+	return log.file.Name(), nil
+}
+
+// start creates the log file of scope with path 'scopes', the lock must be held by caller
+func (directory *Directory) start(scopes []string) (*scopeLog, error) {
+	scope := strings.Join(scopes, "/")
+	if log, ok := directory.logs[scope]; ok {
+		return log, nil
+	}
+	relative := directory.uniquePath(SanitizePath(scopes))
+	path := filepath.Join(directory.path, relative)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	directory.used[relative] = true
+	log := &scopeLog{
+		file: file,
+		entry: &IndexEntry{
+			Scope:   scope,
+			File:    filepath.ToSlash(relative),
+			Outcome: OutcomeRunning,
+			Started: time.Now(),
+		},
+	}
+	directory.logs[scope] = log
+	directory.index = append(directory.index, log.entry)
+	return log, nil
+}
+
+// uniquePath returns relative if no file has used it, otherwise a numbered variant of it
+func (directory *Directory) uniquePath(relative string) string {
+	if !directory.used[relative] {
+		return relative
+	}
+	base := strings.TrimSuffix(relative, ".log")
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d.log", base, i)
+		if !directory.used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// Write appends a message to the log file of scope with path 'scopes', the file is created if the
+// scope hasn't started.
+func (directory *Directory) Write(scopes []string, message string) {
+	directory.lock.Lock()
+	defer directory.lock.Unlock()
+	log, err := directory.start(scopes)
+	if err != nil || log.entry.Outcome != OutcomeRunning {
+		return
+	}
+	_, _ = log.file.WriteString(message + "\n")
+}
+
+// Finish closes the log file of scope with path 'scopes', records its outcome for the index file and
+// returns the path of the log file. The path is empty if the file can't be created.
+func (directory *Directory) Finish(scopes []string, success bool) (string, error) {
+	directory.lock.Lock()
+	defer directory.lock.Unlock()
+	log, err := directory.start(scopes)
+	if err != nil {
+		return "", err
+	}
+	if log.entry.Outcome == OutcomeRunning {
+		_ = log.file.Close()
+		log.entry.Outcome = OutcomeFailed
+		if success {
+			log.entry.Outcome = OutcomeSucceeded
+		}
+		log.entry.Finished = time.Now()
+	}
+	return log.file.Name(), nil
+}
+
+// Close closes log files of scopes which haven't finished and writes the index file, the outcomes
+// of these scopes stay running.
+func (directory *Directory) Close() error {
+	directory.lock.Lock()
+	defer directory.lock.Unlock()
+	for _, log := range directory.logs {
+		if log.entry.Outcome == OutcomeRunning {
+			_ = log.file.Close()
+		}
+	}
+	return directory.writeIndex()
+}
+
+// writeIndex writes the index file, the lock must be held by caller
+func (directory *Directory) writeIndex() error {
+	content, err := json.MarshalIndent(directory.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(directory.path, IndexFileName), content, 0o644)
+}
+
+// SanitizePath returns the relative path of log file of scope with path 'scopes', every scope becomes
+// a path element of which characters other than letters, digits, '.', '-' and '_' are replaced by '_'.
+func SanitizePath(scopes []string) string {
+	elements := make([]string, len(scopes))
+	for i, scope := range scopes {
+		elements[i] = sanitizeElement(scope)
+	}
+	if len(elements) == 0 {
+		elements = []string{"_"}
+	}
+	return filepath.Join(elements...) + ".log"
+}
+
+// sanitizeElement returns a safe file name for scope
+func sanitizeElement(scope string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, scope)
+	if strings.Trim(sanitized, ".") == "" {
+		return strings.Repeat("_", len(sanitized)+1)
+	}
+	return sanitized
+}
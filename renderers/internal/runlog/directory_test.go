@@ -0,0 +1,67 @@
+package runlog_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roberChen/echelon/renderers/internal/runlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SanitizePath(t *testing.T) {
+	assert.Equal(t, filepath.Join("build", "unit_tests__go_.log"), runlog.SanitizePath([]string{"build", "unit tests (go)"}))
+	assert.Equal(t, filepath.Join("___", "__", "_.log"), runlog.SanitizePath([]string{"..", ".", ""}))
+	assert.Equal(t, "_etc_passwd.log", runlog.SanitizePath([]string{"/etc/passwd"}))
+}
+
+func Test_Directory(t *testing.T) {
+	path, err := ioutil.TempDir("", "echelon-run")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	directory, err := runlog.NewDirectory(path)
+	assert.NoError(t, err)
+	first, err := directory.Start([]string{"build", "a b"})
+	assert.NoError(t, err)
+	directory.Write([]string{"build", "a b"}, "compiling")
+	second, err := directory.Start([]string{"build", "a_b"})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(path, "build", "a_b.log"), first)
+	assert.Equal(t, filepath.Join(path, "build", "a_b-2.log"), second)
+	finished, err := directory.Finish([]string{"build", "a b"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, first, finished)
+	directory.Write([]string{"build", "a b"}, "after finish")
+	assert.NoFileExists(t, filepath.Join(path, runlog.IndexFileName))
+	assert.NoError(t, directory.Close())
+
+	content, err := ioutil.ReadFile(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "compiling\n", string(content))
+	indexContent, err := ioutil.ReadFile(filepath.Join(path, runlog.IndexFileName))
+	assert.NoError(t, err)
+	var index []runlog.IndexEntry
+	assert.NoError(t, json.Unmarshal(indexContent, &index))
+	assert.Len(t, index, 2)
+	assert.Equal(t, "build/a b", index[0].Scope)
+	assert.Equal(t, "build/a_b.log", index[0].File)
+	assert.Equal(t, runlog.OutcomeFailed, index[0].Outcome)
+	assert.Equal(t, runlog.OutcomeRunning, index[1].Outcome)
+}
+
+func Test_Directory_CreateError(t *testing.T) {
+	path, err := ioutil.TempDir("", "echelon-run")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	directory, err := runlog.NewDirectory(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Mkdir(filepath.Join(path, "build.log"), 0o755))
+	started, err := directory.Start([]string{"build"})
+	assert.Error(t, err)
+	assert.Empty(t, started)
+	finished, err := directory.Finish([]string{"build"}, false)
+	assert.Error(t, err)
+	assert.Empty(t, finished)
+}
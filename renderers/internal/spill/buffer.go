@@ -0,0 +1,190 @@
+package spill
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// tempFilePattern is the name pattern of temp files of spilled buffers
+const tempFilePattern = "echelon-*.log"
+
+// File is a temp file shared by spilled buffers, so that spilling many buffers keeps a single file open.
+// The file is created by the first buffer spilling into it and removed once all its buffers are closed.
+// Once creating or writing the file has failed, all further spills fail with the same error.
+//
+// File isn't coroutine safe.
+type File struct {
+	dir  string
+	file *os.File
+	// size is the length of content written to file
+	size int64
+	// buffers is the number of spilled buffers which aren't closed
+	buffers int
+	err     error
+}
+
+// NewFile creates a shared temp file in dir, the default directory for temp files is used if dir is
+// empty. The file is created once a buffer spills.
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+// Err returns the error which made spilling fail, it's nil if every spill has succeeded
+func (file *File) Err() error {
+	return file.err
+}
+
+// Path returns the path of temp file, it's empty if no buffer has spilled into it
+func (file *File) Path() string {
+	if file.file == nil {
+		return ""
+	}
+	return file.file.Name()
+}
+
+// append writes p at the end of file and returns the offset of p
+func (file *File) append(p []byte) (int64, error) {
+	if file.err != nil {
+		return 0, file.err
+	}
+	if file.file == nil {
+		created, err := ioutil.TempFile(file.dir, tempFilePattern)
+		if err != nil {
+			file.err = err
+			return 0, err
+		}
+		file.file = created
+		file.size = 0
+	}
+	offset := file.size
+	if _, err := file.file.WriteAt(p, offset); err != nil {
+		file.err = err
+		return 0, err
+	}
+	file.size += int64(len(p))
+	return offset, nil
+}
+
+// release forgets a closed buffer, the temp file is removed once there's no spilled buffer
+func (file *File) release() error {
+	file.buffers--
+	if file.buffers > 0 || file.file == nil {
+		return nil
+	}
+	current := file.file
+	file.file = nil
+	err := current.Close()
+	if removeErr := os.Remove(current.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// extent is a part of content of a buffer in its file
+type extent struct {
+	offset int64
+	length int64
+}
+
+// Buffer is a byte buffer which keeps its content in memory until it spills, after spilling
+// the content is moved to a shared temp file, and all further content is written to the file.
+//
+// Buffer isn't coroutine safe.
+type Buffer struct {
+	memory bytes.Buffer
+	file   *File
+	// extents are the parts of content in file, in order
+	extents []extent
+}
+
+// Write appends p to the buffer
+func (buffer *Buffer) Write(p []byte) (int, error) {
+	if buffer.file == nil {
+		return buffer.memory.Write(p)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	offset, err := buffer.file.append(p)
+	if err != nil {
+		return 0, err
+	}
+	buffer.addExtent(offset, int64(len(p)))
+	return len(p), nil
+}
+
+// WriteString appends s to the buffer
+func (buffer *Buffer) WriteString(s string) (int, error) {
+	return buffer.Write([]byte(s))
+}
+
+// addExtent records that content of length at offset of file belongs to buffer
+func (buffer *Buffer) addExtent(offset, length int64) {
+	if last := len(buffer.extents) - 1; last >= 0 && buffer.extents[last].offset+buffer.extents[last].length == offset {
+		buffer.extents[last].length += length
+		return
+	}
+	buffer.extents = append(buffer.extents, extent{offset: offset, length: length})
+}
+
+// Len returns the number of bytes the buffer keeps in memory
+func (buffer *Buffer) Len() int {
+	return buffer.memory.Len()
+}
+
+// Spilled returns whether the buffer has moved its content to a temp file
+func (buffer *Buffer) Spilled() bool {
+	return buffer.file != nil
+}
+
+// Spill moves content of buffer to file, the content is kept in memory if it fails. Spilling a
+// spilled buffer does nothing.
+func (buffer *Buffer) Spill(file *File) error {
+	if buffer.file != nil {
+		return nil
+	}
+	if buffer.memory.Len() > 0 {
+		offset, err := file.append(buffer.memory.Bytes())
+		if err != nil {
+			return err
+		}
+		buffer.addExtent(offset, int64(buffer.memory.Len()))
+	} else if file.err != nil {
+		return file.err
+	}
+	buffer.memory = bytes.Buffer{}
+	buffer.file = file
+	file.buffers++
+	return nil
+}
+
+// WriteTo writes the whole content of buffer to w, the content is kept in buffer
+func (buffer *Buffer) WriteTo(w io.Writer) (int64, error) {
+	if buffer.file == nil {
+		n, err := w.Write(buffer.memory.Bytes())
+		return int64(n), err
+	}
+	var written int64
+	for _, part := range buffer.extents {
+		n, err := io.Copy(w, io.NewSectionReader(buffer.file.file, part.offset, part.length))
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Close releases the buffer, the temp file is removed once all buffers spilled into it are closed
+func (buffer *Buffer) Close() error {
+	buffer.memory = bytes.Buffer{}
+	if buffer.file == nil {
+		return nil
+	}
+	file := buffer.file
+	buffer.file = nil
+	buffer.extents = nil
+	return file.release()
+}
@@ -0,0 +1,66 @@
+package spill_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roberChen/echelon/renderers/internal/spill"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Buffer_Spill(t *testing.T) {
+	file := spill.NewFile("")
+	var buffer spill.Buffer
+	_, _ = buffer.WriteString("in memory\n")
+	assert.Equal(t, 10, buffer.Len())
+	assert.NoError(t, buffer.Spill(file))
+	assert.True(t, buffer.Spilled())
+	assert.Equal(t, 0, buffer.Len())
+	_, _ = buffer.WriteString("in file\n")
+
+	var out bytes.Buffer
+	_, err := buffer.WriteTo(&out)
+	assert.NoError(t, err)
+	assert.Equal(t, "in memory\nin file\n", out.String())
+
+	path := file.Path()
+	assert.NoError(t, buffer.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_Buffer_Spill_SharedFile(t *testing.T) {
+	file := spill.NewFile("")
+	var first, second spill.Buffer
+	_, _ = first.WriteString("first 1\n")
+	_, _ = second.WriteString("second 1\n")
+	assert.NoError(t, first.Spill(file))
+	assert.NoError(t, second.Spill(file))
+	_, _ = first.WriteString("first 2\n")
+	_, _ = second.WriteString("second 2\n")
+	_, _ = first.WriteString("first 3\n")
+
+	var out bytes.Buffer
+	_, _ = first.WriteTo(&out)
+	_, _ = second.WriteTo(&out)
+	assert.Equal(t, "first 1\nfirst 2\nfirst 3\nsecond 1\nsecond 2\n", out.String())
+
+	path := file.Path()
+	assert.NoError(t, first.Close())
+	assert.FileExists(t, path)
+	assert.NoError(t, second.Close())
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_Buffer_Spill_Error(t *testing.T) {
+	file := spill.NewFile(filepath.Join(os.TempDir(), "echelon-missing", "spill"))
+	var buffer spill.Buffer
+	_, _ = buffer.WriteString("kept\n")
+	assert.Error(t, buffer.Spill(file))
+	assert.Error(t, file.Err())
+	assert.False(t, buffer.Spilled())
+	assert.Equal(t, 5, buffer.Len())
+}
@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"sort"
+	"time"
+)
+
+// Slowest returns at most count leaf spans, which have no nested spans, which took the longest time,
+// from the slowest one. Parents are left out since their time is mostly the time of their children.
+func (recorder *Recorder) Slowest(count int) []*Span {
+	now := time.Now()
+	recorder.lock.Lock()
+	var spans []*Span
+	for _, span := range recorder.order {
+		if len(span.Children) == 0 {
+			spans = append(spans, span)
+		}
+	}
+	recorder.lock.Unlock()
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Duration(now) > spans[j].Duration(now)
+	})
+	if len(spans) > count {
+		spans = spans[:count]
+	}
+	return spans
+}
+
+// CriticalPath returns the chain of scopes which determined the wall time of the run, parents come
+// before their nested scopes.
+//
+// Among the children of a scope, the chain ends with the child which finished last, and every other
+// child in the chain is the one which finished last before the next one started. Every scope in the
+// chain is followed by the critical path through its own children.
+func (recorder *Recorder) CriticalPath() []*Span {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	return criticalPath(recorder.root, time.Now())
+}
+
+// criticalPath returns the critical path through the children of span
+func criticalPath(span *Span, now time.Time) []*Span {
+	var chain []*Span
+	var next *Span
+	inChain := make(map[*Span]bool)
+	for {
+		var candidate *Span
+		for _, child := range span.Children {
+			if inChain[child] || (next != nil && endOf(child, now).After(next.Start)) {
+				continue
+			}
+			if candidate == nil || endOf(child, now).After(endOf(candidate, now)) {
+				candidate = child
+			}
+		}
+		if candidate == nil {
+			break
+		}
+		chain = append([]*Span{candidate}, chain...)
+		inChain[candidate] = true
+		next = candidate
+	}
+	var result []*Span
+	for _, child := range chain {
+		result = append(result, child)
+		result = append(result, criticalPath(child, now)...)
+	}
+	return result
+}
+
+// endOf returns the end of span, which is now if it's running
+func endOf(span *Span, now time.Time) time.Time {
+	if span.End.IsZero() {
+		return now
+	}
+	return span.End
+}
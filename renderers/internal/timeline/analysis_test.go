@@ -0,0 +1,45 @@
+package timeline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+	"github.com/stretchr/testify/assert"
+)
+
+// record creates a recorder with spans of scopes, each of them lasts from start to end seconds after base
+func record(base time.Time, spans map[string][2]int, paths ...[]string) *timeline.Recorder {
+	recorder := timeline.NewRecorder(0)
+	for _, scopes := range paths {
+		recorder.Start(scopes)
+	}
+	for _, span := range recorder.Spans() {
+		bounds := spans[span.Path()]
+		span.Start = base.Add(time.Duration(bounds[0]) * time.Second)
+		span.End = base.Add(time.Duration(bounds[1]) * time.Second)
+		span.Outcome = timeline.Succeeded
+	}
+	return recorder
+}
+
+func paths(spans []*timeline.Span) []string {
+	result := make([]string, len(spans))
+	for i, span := range spans {
+		result[i] = span.Path()
+	}
+	return result
+}
+
+func Test_Recorder_CriticalPath(t *testing.T) {
+	recorder := record(time.Now(), map[string][2]int{
+		"a": {0, 10}, "b": {0, 4}, "c": {5, 20}, "d": {12, 15}, "c/c1": {5, 8}, "c/c2": {8, 20},
+	}, []string{"a"}, []string{"b"}, []string{"c"}, []string{"d"}, []string{"c", "c1"}, []string{"c", "c2"})
+	assert.Equal(t, []string{"b", "c", "c/c1", "c/c2"}, paths(recorder.CriticalPath()))
+	assert.Equal(t, []string{"c/c2", "a", "b"}, paths(recorder.Slowest(3)))
+}
+
+func Test_Recorder_CriticalPath_Instant(t *testing.T) {
+	recorder := record(time.Now(), map[string][2]int{"a": {1, 1}, "b": {1, 1}}, []string{"a"}, []string{"b"})
+	assert.Len(t, recorder.CriticalPath(), 2)
+}
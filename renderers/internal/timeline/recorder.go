@@ -0,0 +1,225 @@
+package timeline
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roberChen/echelon"
+)
+
+// Outcome is the result of a scope
+type Outcome int
+
+// Outcomes of scopes
+const (
+	Running Outcome = iota
+	Succeeded
+	Failed
+	Skipped
+)
+
+// String returns the name of outcome, like "failed"
+func (outcome Outcome) String() string {
+	switch outcome {
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	default:
+		return "running"
+	}
+}
+
+// Span is the record of a scope: when it started and finished, how it finished and the last lines
+// of its output
+type Span struct {
+	Scopes []string
+	Start  time.Time
+	// End is zero while the scope is running
+	End     time.Time
+	Outcome Outcome
+	// Cause is the error which made the scope fail, or the reason why it was skipped
+	Cause    string
+	Parent   *Span
+	Children []*Span
+	// lines are the last lines of output of scope
+	lines []string
+}
+
+// Path returns the path of scope, like "build/test"
+func (span *Span) Path() string {
+	return strings.Join(span.Scopes, "/")
+}
+
+// Duration returns the time scope has taken, it's the time passed until now for running scopes
+func (span *Span) Duration(now time.Time) time.Duration {
+	if span.End.IsZero() {
+		return now.Sub(span.Start)
+	}
+	return span.End.Sub(span.Start)
+}
+
+// Lines returns the last lines of output of scope
+func (span *Span) Lines() []string {
+	return span.lines
+}
+
+// Totals are the numbers of scopes by outcome, and the wall time of the run
+type Totals struct {
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Running   int
+	Wall      time.Duration
+}
+
+// Recorder records spans of all scopes of a run from the events sent to renderers, it keeps the last
+// lines of output of every scope. It's coroutine safe.
+type Recorder struct {
+	lock  sync.Mutex
+	root  *Span
+	spans map[string]*Span
+	// order are the spans of all scopes but the root, in start order
+	order     []*Span
+	tailLines int
+}
+
+// NewRecorder creates a recorder keeping the last tailLines lines of output of every scope
+func NewRecorder(tailLines int) *Recorder {
+	return &Recorder{
+		root:      &Span{Start: time.Now()},
+		spans:     make(map[string]*Span),
+		tailLines: tailLines,
+	}
+}
+
+// Start records the start of scope with path 'scopes' now, spans of its ancestors are created if they
+// haven't started.
+func (recorder *Recorder) Start(scopes []string) {
+	recorder.StartAt(scopes, time.Now())
+}
+
+// StartAt records the start of scope with path 'scopes' at start, which is the time renderers have
+// recorded for the scope. Spans of its ancestors are created if they haven't started.
+func (recorder *Recorder) StartAt(scopes []string, start time.Time) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	span := recorder.span(scopes)
+	if start.Before(span.Start) {
+		span.Start = start
+	}
+}
+
+// span returns the span of scope with path 'scopes', it's created with its ancestors if it doesn't
+// exist. The lock must be held by caller.
+func (recorder *Recorder) span(scopes []string) *Span {
+	if len(scopes) == 0 {
+		return recorder.root
+	}
+	path := strings.Join(scopes, "/")
+	if span, ok := recorder.spans[path]; ok {
+		return span
+	}
+	parent := recorder.span(scopes[:len(scopes)-1])
+	span := &Span{
+		Scopes: append([]string(nil), scopes...),
+		Start:  time.Now(),
+		Parent: parent,
+	}
+	parent.Children = append(parent.Children, span)
+	recorder.spans[path] = span
+	recorder.order = append(recorder.order, span)
+	return span
+}
+
+// Finish records the end and outcome of scope finished by entry now
+func (recorder *Recorder) Finish(entry *echelon.LogScopeFinished) {
+	recorder.FinishAt(entry, time.Now())
+}
+
+// FinishAt records the end and outcome of scope finished by entry at end, which is the time renderers
+// have recorded for the scope
+func (recorder *Recorder) FinishAt(entry *echelon.LogScopeFinished, end time.Time) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	span := recorder.span(entry.GetScopes())
+	if !span.End.IsZero() {
+		return
+	}
+	span.End = end
+	switch {
+	case entry.Skipped():
+		span.Outcome = Skipped
+		span.Cause = entry.SkipReason()
+	case entry.Success():
+		span.Outcome = Succeeded
+	default:
+		span.Outcome = Failed
+		if entry.Cause() != nil {
+			span.Cause = entry.Cause().Error()
+		}
+	}
+}
+
+// AddLines appends lines of text to the output of scope with path 'scopes', only the last lines are kept
+func (recorder *Recorder) AddLines(scopes []string, text string) {
+	if recorder.tailLines <= 0 {
+		return
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	span := recorder.span(scopes)
+	span.lines = append(span.lines, strings.Split(text, "\n")...)
+	if extra := len(span.lines) - recorder.tailLines; extra > 0 {
+		span.lines = span.lines[extra:]
+	}
+}
+
+// Root returns the span of root, whose children are the top level scopes
+func (recorder *Recorder) Root() *Span {
+	return recorder.root
+}
+
+// Spans returns spans of all scopes but the root, in start order
+func (recorder *Recorder) Spans() []*Span {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	return append([]*Span(nil), recorder.order...)
+}
+
+// Bounds returns the start of the first scope and the end of the run, which is the end of root or
+// now if root hasn't finished.
+func (recorder *Recorder) Bounds() (time.Time, time.Time) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	end := recorder.root.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if len(recorder.order) == 0 {
+		return end, end
+	}
+	return recorder.order[0].Start, end
+}
+
+// Totals returns the numbers of scopes by outcome and the wall time of the run
+func (recorder *Recorder) Totals() Totals {
+	start, end := recorder.Bounds()
+	result := Totals{Wall: end.Sub(start)}
+	for _, span := range recorder.Spans() {
+		switch span.Outcome {
+		case Succeeded:
+			result.Succeeded++
+		case Failed:
+			result.Failed++
+		case Skipped:
+			result.Skipped++
+		case Running:
+			result.Running++
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package timeline_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Recorder(t *testing.T) {
+	recorder := timeline.NewRecorder(1)
+	recorder.Start([]string{"build", "test"})
+	recorder.AddLines([]string{"build", "test"}, "one\ntwo")
+	recorder.Finish(echelon.NewLogScopeFailed(errors.New("broken"), "build", "test"))
+	recorder.Start([]string{"lint"})
+	recorder.Finish(echelon.NewLogScopeSkipped("cached", "lint"))
+
+	spans := recorder.Spans()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "build", spans[0].Path())
+	assert.Equal(t, []*timeline.Span{spans[1]}, spans[0].Children)
+	assert.Equal(t, timeline.Failed, spans[1].Outcome)
+	assert.Equal(t, "broken", spans[1].Cause)
+	assert.Equal(t, []string{"two"}, spans[1].Lines())
+	assert.Equal(t, "skipped", spans[2].Outcome.String())
+	assert.Equal(t, "cached", spans[2].Cause)
+	assert.Equal(t, []*timeline.Span{spans[0], spans[2]}, recorder.Root().Children)
+
+	totals := recorder.Totals()
+	assert.Equal(t, 1, totals.Failed)
+	assert.Equal(t, 1, totals.Skipped)
+	assert.Equal(t, 1, totals.Running)
+}
+
+func Test_Recorder_StartAt(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	recorder := timeline.NewRecorder(0)
+	recorder.StartAt([]string{"build"}, start)
+	recorder.FinishAt(echelon.NewLogScopeFinished(true, "build"), start.Add(time.Second))
+	recorder.FinishAt(echelon.NewLogScopeFinished(false, "build"), start.Add(time.Hour))
+
+	span := recorder.Spans()[0]
+	assert.Equal(t, start, span.Start)
+	assert.Equal(t, time.Second, span.Duration(time.Now()))
+	assert.Equal(t, timeline.Succeeded, span.Outcome)
+}
@@ -0,0 +1,241 @@
+package renderers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+)
+
+// junitRootSuiteName is the name of the test suite without test cases holding the messages of root
+const junitRootSuiteName = "(root)"
+
+// junitTestSuites is the root element of JUnit XML reports
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is a test suite made of a top level scope
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+// junitTestCase is a test case made of a leaf scope
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Skipped   *junitSkipped `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitFailure is the failure of a test case with the cause of failure
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSkipped marks a skipped test case
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// JUnitRenderer is a renderer which writes a JUnit XML report once the root finishes. Top level scopes
+// become test suites and leaf scopes become their test cases, named by their paths inside the suites.
+// A top level scope without nested scopes is a suite with a single test case. Failed test cases carry
+// the cause of failure, skipped ones are marked skipped, and messages of scopes are captured in their
+// system-out. Messages and causes of failures of scopes which aren't test cases are captured in the
+// system-out of their suites, and the ones of root in an extra suite named "(root)" without test cases.
+type JUnitRenderer struct {
+	out      io.Writer
+	timeline *timeline.Recorder
+	// messages are the captured messages of scopes, the key is the path of scope
+	messages map[string][]string
+	// lock guards messages, err and the spans of timeline
+	lock sync.Mutex
+	err  error
+}
+
+// NewJUnitRenderer creates a renderer writing the report to out
+func NewJUnitRenderer(out io.Writer) *JUnitRenderer {
+	return &JUnitRenderer{
+		out:      out,
+		timeline: timeline.NewRecorder(0),
+		messages: make(map[string][]string),
+	}
+}
+
+// RenderScopeStarted records the start of scope
+func (r *JUnitRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	if len(entry.GetScopes()) > 0 {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.timeline.Start(entry.GetScopes())
+	}
+}
+
+// RenderScopeFinished records the end and outcome of scope, the report is written once the root finishes
+func (r *JUnitRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.timeline.Finish(entry)
+	if len(entry.GetScopes()) == 0 {
+		r.err = r.write()
+	}
+}
+
+// Err returns the error of writing the report once the root has finished
+func (r *JUnitRenderer) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// RenderMessage captures message for the system-out of its scope
+func (r *JUnitRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	path := strings.Join(entry.GetScopes(), "/")
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages[path] = append(r.messages[path], entry.GetMessage())
+}
+
+// RenderProcess does nothing, progress isn't part of reports
+func (r *JUnitRenderer) RenderProcess(entry *echelon.LogProcessMessage) {}
+
+// Write writes the report of everything recorded so far to out, it's a coroutine safe function
+func (r *JUnitRenderer) Write() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.write()
+}
+
+// write writes the report to out, the lock must be held by caller
+func (r *JUnitRenderer) write() error {
+	start, end := r.timeline.Bounds()
+	report := junitTestSuites{Time: junitSeconds(end.Sub(start))}
+	for _, top := range r.timeline.Root().Children {
+		suite := junitTestSuite{
+			Name:      top.Path(),
+			Time:      junitSeconds(spanEnd(top, end).Sub(top.Start)),
+			Timestamp: top.Start.Format("2006-01-02T15:04:05"),
+		}
+		// messages of a top level scope without nested scopes belong to its only test case
+		leaves := []*timeline.Span{top}
+		if len(top.Children) > 0 {
+			leaves = nil
+			lines := r.outputLines(top, "")
+			walkSpans(top, func(span *timeline.Span) {
+				if len(span.Children) == 0 {
+					leaves = append(leaves, span)
+				} else {
+					lines = append(lines, r.outputLines(span, strings.Join(span.Scopes[len(top.Scopes):], "/")+": ")...)
+				}
+			})
+			suite.SystemOut = joinLines(lines)
+		}
+		for _, leaf := range leaves {
+			testCase := r.testCase(top, leaf, end)
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+	if lines := r.outputLines(r.timeline.Root(), ""); len(lines) > 0 {
+		report.Suites = append(report.Suites, junitTestSuite{
+			Name:      junitRootSuiteName,
+			Time:      report.Time,
+			Timestamp: start.Format("2006-01-02T15:04:05"),
+			SystemOut: joinLines(lines),
+		})
+	}
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(r.out, xml.Header+string(content)+"\n")
+	return err
+}
+
+// testCase returns the test case of leaf in the suite of top level scope top, running scopes last until end
+func (r *JUnitRenderer) testCase(top *timeline.Span, leaf *timeline.Span, end time.Time) junitTestCase {
+	name := leaf.Path()
+	if leaf != top {
+		name = strings.Join(leaf.Scopes[len(top.Scopes):], "/")
+	}
+	result := junitTestCase{
+		Name:      name,
+		ClassName: top.Path(),
+		Time:      junitSeconds(spanEnd(leaf, end).Sub(leaf.Start)),
+		SystemOut: r.systemOut(leaf),
+	}
+	switch leaf.Outcome {
+	case timeline.Failed:
+		result.Failure = &junitFailure{Message: leaf.Cause, Type: "failure", Text: leaf.Cause}
+	case timeline.Running:
+		result.Failure = &junitFailure{Message: unfinishedScopeMessage, Type: "failure", Text: unfinishedScopeMessage}
+	case timeline.Skipped:
+		result.Skipped = &junitSkipped{Message: leaf.Cause}
+	case timeline.Succeeded:
+	}
+	return result
+}
+
+// systemOut returns the captured messages of scope of span
+func (r *JUnitRenderer) systemOut(span *timeline.Span) string {
+	return joinLines(r.messages[span.Path()])
+}
+
+// outputLines returns the captured messages of scope of span followed by the cause of its failure, for
+// scopes which aren't test cases. Every line is prefixed by prefix.
+func (r *JUnitRenderer) outputLines(span *timeline.Span, prefix string) []string {
+	var lines []string
+	for _, message := range r.messages[span.Path()] {
+		lines = append(lines, prefix+message)
+	}
+	if span.Outcome == timeline.Failed && span.Cause != "" {
+		lines = append(lines, prefix+"failed: "+span.Cause)
+	}
+	return lines
+}
+
+// joinLines joins lines ending every line with a new line, it returns an empty string without lines
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// junitSeconds returns duration in seconds with milliseconds, like "1.234"
+func junitSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
@@ -0,0 +1,71 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JUnitRenderer(t *testing.T) {
+	var out bytes.Buffer
+	r := NewJUnitRenderer(&out)
+	for _, scopes := range [][]string{{"unit"}, {"unit", "pkg"}, {"unit", "pkg", "Test_A"}, {"unit", "pkg", "Test_B"}, {"unit", "Test_C"}} {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, scopes...))
+	}
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"unit"}, echelon.InfoLevel, "running tests"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"unit", "pkg", "Test_B"}, echelon.ErrorLevel, "expected <1>"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "unit", "pkg", "Test_A"))
+	r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("assertion failed"), "unit", "pkg", "Test_B"))
+	r.RenderScopeFinished(echelon.NewLogScopeSkipped("flaky", "unit", "Test_C"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"unit", "pkg"}, echelon.InfoLevel, "compiled"))
+	r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("exit status 1"), "unit", "pkg"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "unit"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "lint"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"lint"}, echelon.InfoLevel, "clean"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "lint"))
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.WarnLevel, "cache disabled"))
+	r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("1 of 2 scopes failed")))
+
+	assert.Contains(t, out.String(), xml.Header)
+	var report junitTestSuites
+	assert.NoError(t, xml.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Len(t, report.Suites, 3)
+
+	unit := report.Suites[0]
+	assert.Equal(t, "unit", unit.Name)
+	assert.Equal(t, "running tests\npkg: compiled\npkg: failed: exit status 1\n", unit.SystemOut)
+	assert.Len(t, unit.Cases, 3)
+	assert.Equal(t, "pkg/Test_A", unit.Cases[0].Name)
+	assert.Equal(t, "unit", unit.Cases[0].ClassName)
+	assert.Nil(t, unit.Cases[0].Failure)
+	assert.Equal(t, &junitFailure{Message: "assertion failed", Type: "failure", Text: "assertion failed"}, unit.Cases[1].Failure)
+	assert.Equal(t, "expected <1>\n", unit.Cases[1].SystemOut)
+	assert.Equal(t, &junitSkipped{Message: "flaky"}, unit.Cases[2].Skipped)
+
+	lint := report.Suites[1]
+	assert.Equal(t, 1, lint.Tests)
+	assert.Equal(t, "lint", lint.Cases[0].Name)
+	assert.Equal(t, "clean\n", lint.Cases[0].SystemOut)
+	assert.Equal(t, "", lint.SystemOut)
+
+	root := report.Suites[2]
+	assert.Equal(t, "(root)", root.Name)
+	assert.Equal(t, 0, root.Tests)
+	assert.Equal(t, "cache disabled\nfailed: 1 of 2 scopes failed\n", root.SystemOut)
+}
+
+func Test_JUnitRenderer_Err(t *testing.T) {
+	r := NewJUnitRenderer(failingWriter{})
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "unit"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "unit"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.EqualError(t, r.Err(), "disk full")
+}
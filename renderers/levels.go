@@ -0,0 +1,38 @@
+package renderers
+
+import (
+	"strings"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/terminal"
+)
+
+// levelBadge returns the badge of level, like "[WARN]"
+func levelBadge(level echelon.LogLevel) string {
+	return "[" + strings.ToUpper(level.String()) + "]"
+}
+
+// levelHidden returns true if entry is hidden by the level filter of config, nothing is hidden unless
+// filter is set. Deferred entries are never hidden, they're sent because their scope failed.
+func levelHidden(filter bool, limit echelon.LogLevel, entry *echelon.LogEntryMessage) bool {
+	return filter && entry.Level > limit && !entry.Deferred()
+}
+
+// decorateMessage colors message by its level and adds the level badge to it if badge is true.
+// Every line is colored separately, so that lines can be rendered apart.
+func decorateMessage(colors *terminal.ColorSchema, level echelon.LogLevel, badge bool, message string) string {
+	if badge {
+		message = levelBadge(level) + " " + message
+	}
+	color := colors.LevelColor(uint32(level))
+	if color < 0 {
+		return message
+	}
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = terminal.GetColoredText(color, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
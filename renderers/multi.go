@@ -0,0 +1,73 @@
+package renderers
+
+import (
+	"sync"
+
+	"github.com/roberChen/echelon"
+)
+
+// MultiRenderer is a renderer which passes every entry to several renderers in order, so a run can
+// be rendered by e.g. an InteractiveRenderer and a GanttRenderer at the same time. Since NewLogger takes
+// a single renderer, it's the way to use summary renderers and exporters like ChromeTraceRenderer,
+// OTLPRenderer, JUnitRenderer and TAPRenderer along with the interactive or simple renderer.
+type MultiRenderer struct {
+	renderers []echelon.LogRenderer
+}
+
+// NewMultiRenderer creates a renderer which passes entries to renderers
+func NewMultiRenderer(renderers ...echelon.LogRenderer) *MultiRenderer {
+	return &MultiRenderer{renderers: renderers}
+}
+
+// RenderScopeStarted passes entry to all renderers
+func (r *MultiRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	for _, renderer := range r.renderers {
+		renderer.RenderScopeStarted(entry)
+	}
+}
+
+// RenderScopeFinished passes entry to all renderers
+func (r *MultiRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	for _, renderer := range r.renderers {
+		renderer.RenderScopeFinished(entry)
+	}
+}
+
+// RenderMessage passes entry to all renderers
+func (r *MultiRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	for _, renderer := range r.renderers {
+		renderer.RenderMessage(entry)
+	}
+}
+
+// RenderProcess passes entry to all renderers
+func (r *MultiRenderer) RenderProcess(entry *echelon.LogProcessMessage) {
+	for _, renderer := range r.renderers {
+		renderer.RenderProcess(entry)
+	}
+}
+
+// StartDrawing starts drawing of all renderers which draw continuously, like InteractiveRenderer, and
+// returns once they all have stopped.
+func (r *MultiRenderer) StartDrawing() {
+	var drawing sync.WaitGroup
+	for _, renderer := range r.renderers {
+		if starter, ok := renderer.(interface{ StartDrawing() }); ok {
+			drawing.Add(1)
+			go func() {
+				defer drawing.Done()
+				starter.StartDrawing()
+			}()
+		}
+	}
+	drawing.Wait()
+}
+
+// StopDrawing stops drawing of all renderers which draw, like InteractiveRenderer and GanttRenderer, in order
+func (r *MultiRenderer) StopDrawing() {
+	for _, renderer := range r.renderers {
+		if stopper, ok := renderer.(interface{ StopDrawing() }); ok {
+			stopper.StopDrawing()
+		}
+	}
+}
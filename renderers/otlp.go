@@ -0,0 +1,287 @@
+package renderers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+)
+
+// Kinds and status codes of OTLP spans
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+// otlpValue is an OTLP attribute value, only one of the fields is set
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// otlpAttribute is an OTLP key value pair
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpEvent is an OTLP span event
+type otlpEvent struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+// otlpStatus is the status of an OTLP span
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// otlpSpan is an OTLP span
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+// OTLPRenderer is a renderer which turns scopes into OpenTelemetry spans and exports them as OTLP/JSON to
+// a collector endpoint or a file once the root finishes. Spans are nested by scope path under a span of
+// root, messages become span events and their fields become attributes of events.
+type OTLPRenderer struct {
+	config   *config.OTLPRendererConfig
+	client   *http.Client
+	timeline *timeline.Recorder
+	// events are the events of spans, the key is the path of scope
+	events map[string][]otlpEvent
+	// lock guards events, err and the spans of timeline
+	lock sync.Mutex
+	err  error
+}
+
+// NewOTLPRenderer creates a renderer exporting spans as configured by rendererConfig, a nil config is the
+// default config without File, which exports nothing
+func NewOTLPRenderer(rendererConfig *config.OTLPRendererConfig) *OTLPRenderer {
+	if rendererConfig == nil {
+		rendererConfig = config.NewDefaultOTLPRendererConfig("")
+	}
+	timeout := rendererConfig.Timeout
+	if timeout <= 0 {
+		timeout = config.DefaultOTLPTimeout
+	}
+	return &OTLPRenderer{
+		config:   rendererConfig,
+		client:   &http.Client{Timeout: timeout},
+		timeline: timeline.NewRecorder(0),
+		events:   make(map[string][]otlpEvent),
+	}
+}
+
+// RenderScopeStarted records the start of span of scope
+func (r *OTLPRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	if len(entry.GetScopes()) > 0 {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.timeline.Start(entry.GetScopes())
+	}
+}
+
+// RenderScopeFinished records the end and status of span of scope, spans are exported once the root finishes.
+// Exporting to an endpoint blocks until the collector responds or Timeout of config has passed.
+func (r *OTLPRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	r.lock.Lock()
+	r.timeline.Finish(entry)
+	r.lock.Unlock()
+	if len(entry.GetScopes()) == 0 {
+		err := r.Export()
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.err = err
+	}
+}
+
+// RenderMessage records message as an event of span of its scope
+func (r *OTLPRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	attributes := []otlpAttribute{otlpAttributeOf("level", entry.Level.String())}
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attributes = append(attributes, otlpAttributeOf(key, entry.Fields[key]))
+	}
+	path := strings.Join(entry.GetScopes(), "/")
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events[path] = append(r.events[path], otlpEvent{
+		TimeUnixNano: otlpTime(entry.GetTime()),
+		Name:         entry.GetMessage(),
+		Attributes:   attributes,
+	})
+}
+
+// RenderProcess does nothing, progress isn't part of spans
+func (r *OTLPRenderer) RenderProcess(entry *echelon.LogProcessMessage) {}
+
+// Err returns the error of exporting spans once the root has finished
+func (r *OTLPRenderer) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// Export exports spans of everything recorded so far, spans of scopes which are still running end now.
+// It's a coroutine safe function.
+func (r *OTLPRenderer) Export() error {
+	r.lock.Lock()
+	content, err := json.Marshal(r.request())
+	r.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if r.config.Endpoint == "" && r.config.File == "" {
+		return nil
+	}
+	if r.config.Endpoint == "" {
+		return ioutil.WriteFile(r.config.File, content, 0o644)
+	}
+	request, err := http.NewRequest(http.MethodPost, r.config.Endpoint, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range r.config.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = ioutil.ReadAll(response.Body)
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("exporting spans to %s: %s", r.config.Endpoint, response.Status)
+	}
+	return nil
+}
+
+// request returns the OTLP/JSON export request of all spans, the lock must be held by caller
+func (r *OTLPRenderer) request() interface{} {
+	_, end := r.timeline.Bounds()
+	traceID := otlpID(16)
+	root := r.timeline.Root()
+	ids := map[*timeline.Span]string{root: otlpID(8)}
+	rootStart := root.Start
+	if spans := r.timeline.Spans(); len(spans) > 0 {
+		rootStart = spans[0].Start
+	}
+	spans := []otlpSpan{{
+		TraceID:           traceID,
+		SpanID:            ids[root],
+		Name:              r.config.ServiceName,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: otlpTime(rootStart),
+		EndTimeUnixNano:   otlpTime(end),
+		Events:            r.events[""],
+		Status:            otlpStatusOf(root),
+	}}
+	walkSpans(root, func(span *timeline.Span) {
+		ids[span] = otlpID(8)
+		spans = append(spans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            ids[span],
+			ParentSpanID:      ids[span.Parent],
+			Name:              span.Scopes[len(span.Scopes)-1],
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: otlpTime(span.Start),
+			EndTimeUnixNano:   otlpTime(spanEnd(span, end)),
+			Attributes: []otlpAttribute{
+				otlpAttributeOf("echelon.path", span.Path()),
+				otlpAttributeOf("echelon.outcome", span.Outcome.String()),
+			},
+			Events: r.events[span.Path()],
+			Status: otlpStatusOf(span),
+		})
+	})
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{otlpAttributeOf("service.name", r.config.ServiceName)},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "echelon"},
+				"spans": spans,
+			}},
+		}},
+	}
+}
+
+// otlpStatusOf returns the status of span by its outcome, running spans have no status
+func otlpStatusOf(span *timeline.Span) otlpStatus {
+	switch span.Outcome {
+	case timeline.Succeeded, timeline.Skipped:
+		return otlpStatus{Code: otlpStatusOK}
+	case timeline.Failed:
+		return otlpStatus{Code: otlpStatusError, Message: span.Cause}
+	case timeline.Running:
+	}
+	return otlpStatus{}
+}
+
+// otlpAttributeOf returns the attribute of key with value converted to the matching OTLP type
+func otlpAttributeOf(key string, value interface{}) otlpAttribute {
+	var result otlpValue
+	switch typed := value.(type) {
+	case string:
+		result.StringValue = &typed
+	case bool:
+		result.BoolValue = &typed
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		text := fmt.Sprintf("%d", typed)
+		result.IntValue = &text
+	case float32:
+		double := float64(typed)
+		result.DoubleValue = &double
+	case float64:
+		result.DoubleValue = &typed
+	default:
+		text := fmt.Sprint(typed)
+		result.StringValue = &text
+	}
+	return otlpAttribute{Key: key, Value: result}
+}
+
+// otlpTime returns t in nanoseconds since the Unix epoch, as a string like OTLP/JSON expects
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpID returns a random hex encoded id of size bytes
+func otlpID(size int) string {
+	id := make([]byte, size)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
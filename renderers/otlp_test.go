@@ -0,0 +1,108 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// otlpExport is the part of OTLP/JSON export request checked by tests
+type otlpExport struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+// renderOTLPRun renders a run with a failed scope nested in a succeeded one
+func renderOTLPRun(r *OTLPRenderer) {
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build", "test"))
+	message := echelon.NewLogEntryMessage([]string{"build", "test"}, echelon.ErrorLevel, "assertion failed")
+	message.SetField("test", "Test_Foo")
+	message.SetField("attempt", 2)
+	r.RenderMessage(message)
+	r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("exit status 1"), "build", "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "build"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+}
+
+func Test_OTLPRenderer_Endpoint(t *testing.T) {
+	var export otlpExport
+	var contentType, authorization string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/v1/traces", request.URL.Path)
+		contentType = request.Header.Get("Content-Type")
+		authorization = request.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(request.Body).Decode(&export))
+	}))
+	defer collector.Close()
+	rendererConfig := config.NewDefaultOTLPRendererConfig("")
+	rendererConfig.Endpoint = collector.URL + "/v1/traces"
+	rendererConfig.Headers = map[string]string{"Authorization": "Bearer t0ken"}
+	r := NewOTLPRenderer(rendererConfig)
+	renderOTLPRun(r)
+	assert.NoError(t, r.Err())
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "Bearer t0ken", authorization)
+
+	spans := export.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 3)
+	root, build, test := spans[0], spans[1], spans[2]
+	assert.Equal(t, "echelon", root.Name)
+	assert.Equal(t, otlpStatusError, root.Status.Code)
+	assert.Equal(t, root.SpanID, build.ParentSpanID)
+	assert.Equal(t, build.SpanID, test.ParentSpanID)
+	assert.Equal(t, root.TraceID, test.TraceID)
+	assert.Equal(t, otlpStatusOK, build.Status.Code)
+	assert.Equal(t, otlpStatus{Code: otlpStatusError, Message: "exit status 1"}, test.Status)
+	assert.Len(t, test.Events, 1)
+	assert.Equal(t, "assertion failed", test.Events[0].Name)
+	assert.Equal(t, "attempt", test.Events[0].Attributes[1].Key)
+	assert.Equal(t, "2", *test.Events[0].Attributes[1].Value.IntValue)
+	assert.Equal(t, "Test_Foo", *test.Events[0].Attributes[2].Value.StringValue)
+}
+
+func Test_OTLPRenderer_EndpointError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+	rendererConfig := config.NewDefaultOTLPRendererConfig("")
+	rendererConfig.Endpoint = collector.URL
+	r := NewOTLPRenderer(rendererConfig)
+	renderOTLPRun(r)
+	assert.Error(t, r.Err())
+}
+
+func Test_OTLPRenderer_File(t *testing.T) {
+	file, err := ioutil.TempFile("", "echelon-*.json")
+	assert.NoError(t, err)
+	_ = file.Close()
+	defer os.Remove(file.Name())
+	r := NewOTLPRenderer(config.NewDefaultOTLPRendererConfig(file.Name()))
+	renderOTLPRun(r)
+	assert.NoError(t, r.Err())
+	content, err := ioutil.ReadFile(file.Name())
+	assert.NoError(t, err)
+	var export otlpExport
+	assert.NoError(t, json.Unmarshal(content, &export))
+	assert.Len(t, export.ResourceSpans[0].ScopeSpans[0].Spans, 3)
+}
+
+func Test_OTLPRenderer_NilConfig(t *testing.T) {
+	r := NewOTLPRenderer(nil)
+	assert.Equal(t, config.DefaultOTLPTimeout, r.client.Timeout)
+	renderOTLPRun(r)
+	assert.NoError(t, r.Err())
+}
@@ -0,0 +1,5 @@
+package renderers
+
+// unfinishedScopeMessage is the failure message of scopes which haven't finished when a report is written,
+// it's shared by the JUnit and TAP reports.
+const unfinishedScopeMessage = "scope did not finish"
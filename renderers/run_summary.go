@@ -0,0 +1,53 @@
+package renderers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+	"github.com/roberChen/echelon/utils"
+)
+
+// writeSlowestScopes writes a table of at most count leaf scopes which took the longest time to out
+func writeSlowestScopes(out io.Writer, recorder *timeline.Recorder, count int) {
+	spans := recorder.Slowest(count)
+	if len(spans) == 0 {
+		return
+	}
+	writeSpanTable(out, "Slowest scopes:", spans, func(span *timeline.Span) string {
+		return span.Path()
+	})
+}
+
+// writeCriticalPath writes the chain of scopes which determined the wall time of the run to out,
+// nested scopes are indented under their parents.
+func writeCriticalPath(out io.Writer, recorder *timeline.Recorder) {
+	spans := recorder.CriticalPath()
+	if len(spans) == 0 {
+		return
+	}
+	writeSpanTable(out, "Critical path:", spans, func(span *timeline.Span) string {
+		return strings.Repeat("  ", len(span.Scopes)-1) + span.Scopes[len(span.Scopes)-1]
+	})
+}
+
+// writeSpanTable writes title and a line for every span with its right aligned duration and its name
+func writeSpanTable(out io.Writer, title string, spans []*timeline.Span, name func(span *timeline.Span) string) {
+	now := time.Now()
+	durations := make([]string, len(spans))
+	width := 0
+	for i, span := range spans {
+		durations[i] = utils.FormatDuration(span.Duration(now), true)
+		if len(durations[i]) > width {
+			width = len(durations[i])
+		}
+	}
+	var table strings.Builder
+	table.WriteString("\n" + title + "\n")
+	for i, span := range spans {
+		table.WriteString(fmt.Sprintf("  %*s  %s\n", width, durations[i], name(span)))
+	}
+	_, _ = io.WriteString(out, table.String())
+}
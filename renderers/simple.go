@@ -3,36 +3,81 @@ package renderers
 import (
 	"fmt"
 	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
 	"github.com/roberChen/echelon/renderers/internal/console"
+	"github.com/roberChen/echelon/renderers/internal/runlog"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
 	"github.com/roberChen/echelon/terminal"
 	"github.com/roberChen/echelon/utils"
 	"io"
 	"strings"
+	"sync"
 	"time"
 )
 
 // SimpleRenderer is a simple renderer with an io.Writer for output, a color for output
 // color, and a map to save time  stamps. The key of time stamps is the path of scope.
+//
+// Its methods have pointer receivers, so *SimpleRenderer is the LogRenderer, a SimpleRenderer
+// value isn't one.
 type SimpleRenderer struct {
 	out        io.Writer
 	colors     *terminal.ColorSchema
+	config     *config.SimpleRendererConfig
 	startTimes map[string]time.Time
+	// progresses are progress states of scopes with progress bar, the key is the path of scope
+	progresses map[string]*simpleProgress
+	// groups buffers output of scopes in grouped mode, it's nil for other modes
+	groups *groupedOutput
+	// repeats are the last messages of scopes for collapsing repeated messages, the key is the path of scope
+	repeats map[string]*repeatedMessage
+	// logs writes messages of scopes to files under LogDirectory, it's nil if there's no LogDirectory
+	logs *runlog.Directory
+	// timeline records spans of all scopes for reports at the end of run
+	timeline *timeline.Recorder
+	// err is the error of creating or closing the run directory or of spilling grouped output, guarded by errLock
+	err     error
+	errLock sync.Mutex
 }
 
 // NewSimpleRenderer creates a simple renderer
 func NewSimpleRenderer(out io.Writer, colors *terminal.ColorSchema) *SimpleRenderer {
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	if colors != nil {
+		rendererConfig.Colors = colors
+	}
+	return NewSimpleRendererWithConfig(out, rendererConfig)
+}
+
+// NewSimpleRendererWithConfig creates a simple renderer with config
+func NewSimpleRendererWithConfig(out io.Writer, rendererConfig *config.SimpleRendererConfig) *SimpleRenderer {
+	if rendererConfig == nil {
+		rendererConfig = config.NewDefaultSimpleRendererConfig()
+	}
+	colors := rendererConfig.Colors
 	if colors == nil {
 		colors = terminal.DefaultColorSchema()
 	}
 	_ = console.PrepareTerminalEnvironment()
-	return &SimpleRenderer{
+	result := &SimpleRenderer{
 		out:        out,
 		colors:     colors,
+		config:     rendererConfig,
 		startTimes: make(map[string]time.Time),
+		progresses: make(map[string]*simpleProgress),
+		repeats:    make(map[string]*repeatedMessage),
+		timeline:   timeline.NewRecorder(rendererConfig.FailureReportLines),
+	}
+	if rendererConfig.Grouped || rendererConfig.OutputOnFailureOnly {
+		result.groups = newGroupedOutput(rendererConfig.GroupedMemoryLimit, rendererConfig.SpillDirectory)
+	}
+	if rendererConfig.LogDirectory != "" {
+		result.logs, result.err = runlog.NewDirectory(rendererConfig.LogDirectory)
 	}
+	return result
 }
 // RenderScopeStarted function of SimpleRenderer, it will start rendering an message of entry.
-func (r SimpleRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+func (r *SimpleRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
 	scopes := entry.GetScopes()
 	level := len(scopes)
 	if level == 0 {
@@ -44,55 +89,194 @@ func (r SimpleRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
 		return
 	}
 	r.startTimes[timeKey] = time.Now()
+	r.timeline.StartAt(scopes, r.startTimes[timeKey])
+	if r.groups != nil {
+		r.groups.start(scopes)
+	}
+	if r.logs != nil {
+		_, err := r.logs.Start(scopes)
+		r.setErr(err)
+	}
+	if entry.GetProgressSize() != echelon.NoProgress {
+		r.progresses[timeKey] = newSimpleProgress(entry.GetProgressSize(), entry.GetOptions())
+	}
+	if r.config.OutputOnFailureOnly {
+		return
+	}
 	lastScope := scopes[level-1]
 	message := terminal.GetColoredText(r.colors.NeutralColor, fmt.Sprintf("Started %s", quotedIfNeeded(lastScope)))
-	r.renderEntry(message)
+	r.renderEntry(scopes, "", message)
 }
 
 // RenderScopeFinished will render a finished entry, which will print to task result of an entry.
-func (r SimpleRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+// In grouped mode, the buffered output is written once a top level scope or the root finishes.
+// In failure only mode, the buffered output of a succeeded scope is replaced by its result line, and
+// the buffered output of a failed scope is written at once.
+func (r *SimpleRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
 	scopes := entry.GetScopes()
 	level := len(scopes)
+	now := time.Now()
+	r.timeline.FinishAt(entry, now)
 	if level == 0 {
+		r.summarizeAllRepeats()
+		r.Flush()
+		if r.logs != nil {
+			r.setErr(r.logs.Close())
+		}
+		r.writeSummaries()
 		return
 	}
-	now := time.Now()
 	startTime := now
 	if t, ok := r.startTimes[strings.Join(scopes, "/")]; ok {
 		startTime = t
 	}
 	duration := now.Sub(startTime)
+	delete(r.progresses, strings.Join(scopes, "/"))
+	r.summarizeRepeats(scopes)
 	formatedDuration := utils.FormatDuration(duration, true)
 	lastScope := scopes[level-1]
 	if entry.Success() {
+		if r.config.OutputOnFailureOnly {
+			r.groups.discard(scopes)
+		}
+		if r.logs != nil {
+			_, err := r.logs.Finish(scopes, true)
+			r.setErr(err)
+		}
 		message := fmt.Sprintf("%s succeeded in %s!", quotedIfNeeded(lastScope), formatedDuration)
-		coloredMessage := terminal.GetColoredText(r.colors.SuccessColor, message)
-		r.renderEntry(coloredMessage)
+		color := r.colors.SuccessColor
+		if entry.Skipped() {
+			message = fmt.Sprintf("%s skipped!", quotedIfNeeded(lastScope))
+			if entry.SkipReason() != "" {
+				message = fmt.Sprintf("%s skipped: %s", quotedIfNeeded(lastScope), entry.SkipReason())
+			}
+			color = r.colors.NeutralColor
+		}
+		r.renderEntry(scopes, "", terminal.GetColoredText(color, message))
 	} else {
 		message := fmt.Sprintf("%s failed in %s!", quotedIfNeeded(lastScope), formatedDuration)
+		if entry.Cause() != nil {
+			message = fmt.Sprintf("%s failed in %s: %s", quotedIfNeeded(lastScope), formatedDuration, entry.Cause())
+		}
 		coloredMessage := terminal.GetColoredText(r.colors.NeutralColor, message)
-		r.renderEntry(coloredMessage)
+		r.renderEntry(scopes, "", coloredMessage)
+		if r.logs != nil {
+			path, err := r.logs.Finish(scopes, false)
+			r.setErr(err)
+			if path != "" {
+				r.renderEntry(scopes, "", "full log: "+path)
+			}
+		}
+		if r.config.OutputOnFailureOnly {
+			r.groups.detach(scopes)
+		}
+	}
+	if r.groups != nil {
+		r.groups.finish(scopes, r.out)
+	}
+}
+
+// writeSummaries writes the reports configured to be printed once the root finishes
+func (r *SimpleRenderer) writeSummaries() {
+	if r.config.SlowestScopes > 0 {
+		writeSlowestScopes(r.out, r.timeline, r.config.SlowestScopes)
+	}
+	if r.config.CriticalPath {
+		writeCriticalPath(r.out, r.timeline)
+	}
+	if r.config.FailureReport {
+		writeFailureReport(r.out, r.colors, r.timeline)
+	}
+}
+
+// Flush writes all output buffered in grouped or failure only mode, including output of scopes which haven't finished yet
+func (r *SimpleRenderer) Flush() {
+	if r.groups != nil {
+		r.groups.flush(r.out)
+	}
+}
+
+// Err returns the first error of creating the run directory of LogDirectory, creating log files of
+// scopes or writing its index once the root has finished, log files aren't written if the directory
+// can't be created. It returns the error of spilling grouped output to SpillDirectory as well, output
+// is kept in memory once spilling fails.
+func (r *SimpleRenderer) Err() error {
+	r.errLock.Lock()
+	defer r.errLock.Unlock()
+	return r.err
+}
+
+// setErr records err unless an error has been recorded
+func (r *SimpleRenderer) setErr(err error) {
+	r.errLock.Lock()
+	defer r.errLock.Unlock()
+	if r.err == nil {
+		r.err = err
 	}
 }
 
 // RenderMessage will render message from entry for simple renderer, it sends message of 
-// entry to renderEntry of renderer.
-func (r SimpleRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
-	r.renderEntry(entry.GetMessage())
+// entry colored by its level to renderEntry of renderer. Messages more verbose than the
+// level of config are ignored if it filters levels, but they're still written to the log file of scope.
+func (r *SimpleRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	if r.logs != nil && len(entry.GetScopes()) > 0 {
+		r.logs.Write(entry.GetScopes(), entry.GetMessage())
+	}
+	if levelHidden(r.config.FilterLevel, r.config.Level, entry) || r.collapseRepeat(entry) {
+		return
+	}
+	message := decorateMessage(r.colors, entry.Level, r.config.LevelBadges, entry.GetMessage())
+	r.renderEntry(entry.GetScopes(), entry.Level.String(), message)
+	if len(entry.GetScopes()) > 0 {
+		r.timeline.AddLines(entry.GetScopes(), message)
+	}
+}
+
+// RenderProcess function of SimpleRenderer, it updates progress of scope specified by entry and
+// prints a progress line when a new step of ProgressStep is reached or ProgressInterval has passed
+// since the last line of scope.
+func (r *SimpleRenderer) RenderProcess(entry *echelon.LogProcessMessage) {
+	scopes := entry.GetScopes()
+	progress, ok := r.progresses[strings.Join(scopes, "/")]
+	if !ok || len(scopes) == 0 {
+		return
+	}
+	progress.update(entry)
+	if !progress.shouldReport(r.config.ProgressStep, r.config.ProgressInterval) {
+		return
+	}
+	message := fmt.Sprintf("%s %s", quotedIfNeeded(scopes[len(scopes)-1]), progress.report())
+	r.renderEntry(scopes, "", terminal.GetColoredText(r.colors.NeutralColor, message))
 }
 
-// RenderProcess function of SimpleRenderer, it will do nothing, simple renderer doesn't 
-// support process rendering
-func (r SimpleRenderer) RenderProcess(entry *echelon.LogProcessMessage) {}
+// renderEntry will render message of simple renderer, it directly output the message to io.Writer of SimpleRenderer.
+// If prefix mode is configured, every line of message is prefixed with scope path and level (empty for
+// lines which are not log messages).
+func (r *SimpleRenderer) renderEntry(scopes []string, level string, message string) {
+	if !r.config.Prefix {
+		r.write(scopes, message+"\n")
+		return
+	}
+	prefix := r.linePrefix(scopes, level)
+	var lines strings.Builder
+	for _, line := range strings.Split(message, "\n") {
+		lines.WriteString(prefix + line + "\n")
+	}
+	r.write(scopes, lines.String())
+}
 
-// renderEntry will render message of simple renderer, it directly output the message to io.Writer of SimpleRenderer
-func (r SimpleRenderer) renderEntry(message string) {
-	_, _ = r.out.Write([]byte(message + "\n"))
+// write outputs text of scope with path 'scopes', it's buffered in grouped mode.
+func (r *SimpleRenderer) write(scopes []string, text string) {
+	if r.groups != nil && len(scopes) > 0 {
+		r.setErr(r.groups.write(scopes, text))
+		return
+	}
+	_, _ = r.out.Write([]byte(text))
 }
 
 // ScopeHasStarted returns whether the scope specified by path 'scpoes' has started. A finished scope is still 
 // started.
-func (r SimpleRenderer) ScopeHasStarted(scopes []string) bool {
+func (r *SimpleRenderer) ScopeHasStarted(scopes []string) bool {
 	level := len(scopes)
 	if level == 0 {
 		return true
@@ -0,0 +1,70 @@
+package renderers
+
+import (
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/roberChen/echelon/terminal"
+)
+
+// linePrefix returns the prefix of lines produced by scope with path 'scopes', like docker-compose
+// does: an optional timestamp, the colored scope path, an optional level and a separator.
+func (r *SimpleRenderer) linePrefix(scopes []string, level string) string {
+	var prefix strings.Builder
+	if r.config.PrefixTimestampFormat != "" {
+		prefix.WriteString(time.Now().Format(r.config.PrefixTimestampFormat) + " ")
+	}
+	path := fitWidth(r.prefixPath(scopes), r.config.PrefixWidth)
+	if colors := r.config.PrefixColors; len(colors) > 0 {
+		path = terminal.GetColoredText(colors[scopeColorIndex(scopes, len(colors))], path)
+	}
+	prefix.WriteString(path)
+	if r.config.PrefixLevel {
+		prefix.WriteString(" " + fitWidth(strings.ToUpper(level), len("debug")))
+	}
+	prefix.WriteString(" | ")
+	return prefix.String()
+}
+
+// prefixPath returns the path of scope for prefixes, all but the last scope are shortened to their
+// first characters if abbreviation is configured.
+func (r *SimpleRenderer) prefixPath(scopes []string) string {
+	if !r.config.PrefixAbbreviate || len(scopes) == 0 {
+		return strings.Join(scopes, "/")
+	}
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes[:len(scopes)-1] {
+		parts[i] = scope
+		if runes := []rune(scope); len(runes) > 0 {
+			parts[i] = string(runes[0])
+		}
+	}
+	parts[len(scopes)-1] = scopes[len(scopes)-1]
+	return strings.Join(parts, "/")
+}
+
+// scopeColorIndex returns a stable index out of count for scope with path 'scopes'
+func scopeColorIndex(scopes []string, count int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.Join(scopes, "/")))
+	return int(hash.Sum32() % uint32(count))
+}
+
+// fitWidth pads text with spaces to width, or truncates its head with an ellipsis if it's wider.
+// Zero width keeps text as is.
+func fitWidth(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	textWidth := runewidth.StringWidth(text)
+	if textWidth <= width {
+		return text + strings.Repeat(" ", width-textWidth)
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && runewidth.StringWidth(string(runes))+1 > width {
+		runes = runes[1:]
+	}
+	return runewidth.FillRight("…"+string(runes), width)
+}
@@ -0,0 +1,102 @@
+package renderers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/utils"
+)
+
+// simpleProgress is the progress state of a scope in SimpleRenderer
+type simpleProgress struct {
+	total int64
+	now   int64
+	// bytes tells whether progress is counted in bytes, si tells whether to use SI units for them
+	bytes bool
+	si    bool
+	// lastPercentage, lastTime and lastNow are the percentage, time and progress of the last reported line
+	lastPercentage int
+	lastTime       time.Time
+	lastNow        int64
+}
+
+// newSimpleProgress creates progress state of scope, bytes units are used if the options
+// of scope contain byte decorators.
+func newSimpleProgress(total int64, options echelon.ScopeOptions) *simpleProgress {
+	result := &simpleProgress{
+		total:    total,
+		lastTime: time.Now(),
+	}
+	for _, decorator := range options.Decorators {
+		switch decorator {
+		case echelon.BytesDecorator, echelon.BytesRateDecorator:
+			result.bytes = true
+		case echelon.SIBytesDecorator, echelon.SIBytesRateDecorator:
+			result.bytes = true
+			result.si = true
+		}
+	}
+	return result
+}
+
+// update applies progress message to the state
+func (progress *simpleProgress) update(entry *echelon.LogProcessMessage) {
+	if entry.Addpercentage != 0 {
+		progress.now += progress.total * int64(entry.Addpercentage) / 100
+	}
+	if entry.Percentage != 0 {
+		progress.now = progress.total * int64(entry.Percentage) / 100
+	}
+	if entry.Progress != 0 {
+		progress.now = entry.Progress
+	}
+	if entry.Addprogress != 0 {
+		progress.now += entry.Addprogress
+	}
+	if progress.now > progress.total {
+		progress.now = progress.total
+	}
+}
+
+// percentage returns current progress in percent
+func (progress *simpleProgress) percentage() int {
+	if progress.total <= 0 {
+		return 0
+	}
+	return int(100 * progress.now / progress.total)
+}
+
+// shouldReport returns whether a new line should be reported, with a step in percent and an interval
+// which are disabled by zero. The progress must have changed since the last line.
+func (progress *simpleProgress) shouldReport(step int, interval time.Duration) bool {
+	if progress.now == progress.lastNow {
+		return false
+	}
+	if step > 0 && progress.percentage()/step > progress.lastPercentage/step {
+		return true
+	}
+	return interval > 0 && time.Since(progress.lastTime) >= interval
+}
+
+// report returns the progress line with percentage, counters and rate since last line, and
+// marks the line as reported.
+func (progress *simpleProgress) report() string {
+	now := time.Now()
+	rate := 0.0
+	if elapsed := now.Sub(progress.lastTime).Seconds(); elapsed > 0 {
+		rate = float64(progress.now-progress.lastNow) / elapsed
+	}
+	var counters string
+	if progress.bytes {
+		counters = fmt.Sprintf("%s/%s, %s/s", utils.FormatBytes(progress.now, progress.si),
+			utils.FormatBytes(progress.total, progress.si), utils.FormatBytes(int64(rate), progress.si))
+	} else {
+		counters = fmt.Sprintf("%d/%d, %.1f/s", progress.now, progress.total, rate)
+	}
+	percentage := progress.percentage()
+	progress.lastTime = now
+	progress.lastNow = progress.now
+	progress.lastPercentage = percentage
+	return fmt.Sprintf("%d%% (%s)", percentage, counters)
+}
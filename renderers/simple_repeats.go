@@ -0,0 +1,66 @@
+package renderers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+)
+
+// repeatedMessage is the last message of a scope and the number of times it has repeated
+type repeatedMessage struct {
+	key     string
+	last    *echelon.LogEntryMessage
+	repeats int
+}
+
+// collapseRepeat returns whether entry repeats the last message of its scope and is collapsed. A message
+// which doesn't repeat prints the summary of repetitions of the last message first.
+func (r *SimpleRenderer) collapseRepeat(entry *echelon.LogEntryMessage) bool {
+	mode := r.config.CollapseRepeatedMessages
+	if mode == config.NoCollapse {
+		return false
+	}
+	key := mode.RepeatKey(entry.GetMessage())
+	path := strings.Join(entry.GetScopes(), "/")
+	if repeated, ok := r.repeats[path]; ok && repeated.key == key {
+		repeated.last = entry
+		repeated.repeats++
+		return true
+	}
+	r.summarizeRepeats(entry.GetScopes())
+	r.repeats[path] = &repeatedMessage{key: key, last: entry, repeats: 1}
+	return false
+}
+
+// summarizeRepeats prints the last repeated message of scope with path 'scopes' with a counter like
+// "waiting for lock... (x42)" if it has repeated, and forgets it.
+func (r *SimpleRenderer) summarizeRepeats(scopes []string) {
+	path := strings.Join(scopes, "/")
+	repeated, ok := r.repeats[path]
+	if !ok {
+		return
+	}
+	delete(r.repeats, path)
+	if repeated.repeats < 2 {
+		return
+	}
+	text := fmt.Sprintf("%s (x%d)", repeated.last.GetMessage(), repeated.repeats)
+	message := decorateMessage(r.colors, repeated.last.Level, r.config.LevelBadges, text)
+	r.renderEntry(scopes, repeated.last.Level.String(), message)
+}
+
+// summarizeAllRepeats prints the summaries of repeated messages of all scopes ordered by path, which
+// includes root and scopes which haven't finished. It's called once the root finishes.
+func (r *SimpleRenderer) summarizeAllRepeats() {
+	paths := make([]string, 0, len(r.repeats))
+	for path := range r.repeats {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		r.summarizeRepeats(r.repeats[path].last.GetScopes())
+	}
+}
@@ -2,10 +2,28 @@
 package renderers
 
 import (
+	"bytes"
+	"errors"
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/config"
+	"github.com/roberChen/echelon/terminal"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 )
 
+// durationPattern matches durations formatted by utils.FormatDuration
+var durationPattern = regexp.MustCompile(`\d+\.\ds|\d+s|\d{2}(:\d{2}){1,2}`)
+
+// withoutDurations replaces all durations in output by "<d>", since they depend on timing of tests
+func withoutDurations(output string) string {
+	return durationPattern.ReplaceAllString(output, "<d>")
+}
+
 func Test_quotedIfNeeded(t *testing.T) {
 	assert.Equal(t, "'foo'", quotedIfNeeded("foo"))
 
@@ -17,3 +35,252 @@ func Test_quotedIfNeeded(t *testing.T) {
 	assert.Equal(t, "\"foo\" task", quotedIfNeeded("\"foo\" task"))
 	assert.Equal(t, "task \"foo\" has finished", quotedIfNeeded("task \"foo\" has finished"))
 }
+
+func Test_SimpleRenderer_RenderProcess(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Colors.NeutralColor = -1
+	rendererConfig.ProgressStep = 25
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(100, "download"))
+	for i := 0; i < 10; i++ {
+		progress := echelon.NewLogProcessMessage("download")
+		progress.Addprogress = 10
+		r.RenderProcess(progress)
+	}
+	assert.Equal(t, 4, strings.Count(out.String(), "'download' "))
+	assert.Contains(t, out.String(), "'download' 30% (30/100, ")
+	assert.Contains(t, out.String(), "'download' 100% (100/100, ")
+}
+
+func Test_SimpleRenderer_Prefix(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Prefix = true
+	rendererConfig.PrefixAbbreviate = true
+	rendererConfig.PrefixWidth = 12
+	rendererConfig.PrefixColors = nil
+	rendererConfig.Colors.LevelColors = nil
+	rendererConfig.PrefixLevel = true
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "tests", "unit"}, echelon.WarnLevel, "first\nsecond"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "integration-tests"}, echelon.InfoLevel, "third"))
+	assert.Equal(t, "b/t/unit     WARN  | first\n"+
+		"b/t/unit     WARN  | second\n"+
+		"…ation-tests INFO  | third\n", out.String())
+}
+
+func Test_fitWidth(t *testing.T) {
+	assert.Equal(t, "foo", fitWidth("foo", 0))
+	assert.Equal(t, "foo  ", fitWidth("foo", 5))
+	assert.Equal(t, "…obar", fitWidth("foobar", 5))
+}
+
+func Test_SimpleRenderer_Grouped(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Grouped = true
+	rendererConfig.GroupedMemoryLimit = 16
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	message := func(text string, scopes ...string) {
+		r.RenderMessage(echelon.NewLogEntryMessage(scopes, echelon.InfoLevel, text))
+	}
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "a"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "b"))
+	message("a1", "a")
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "a", "c"))
+	message("b1", "b")
+	message("c1", "a", "c")
+	message("a2", "a")
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "a", "c"))
+	assert.Equal(t, "", out.String())
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "a"))
+	output := out.String()
+	assert.True(t, strings.Index(output, "a1") < strings.Index(output, "'c'"))
+	assert.True(t, strings.Index(output, "c1") < strings.Index(output, "a2"))
+	assert.True(t, strings.Index(output, "a2") < strings.Index(output, "'a' succeeded"))
+	assert.NotContains(t, output, "b1")
+	r.Flush()
+	assert.Contains(t, out.String(), "b1")
+}
+
+func Test_SimpleRenderer_Grouped_SpillError(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Colors = &terminal.ColorSchema{}
+	rendererConfig.Grouped = true
+	rendererConfig.GroupedMemoryLimit = 1
+	rendererConfig.SpillDirectory = filepath.Join(os.TempDir(), "echelon-missing", "spill")
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"a"}, echelon.InfoLevel, "first"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"a"}, echelon.InfoLevel, "second"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.Error(t, r.Err())
+	assert.Equal(t, "first\nsecond\n", out.String())
+}
+
+func Test_SimpleRenderer_OutputOnFailureOnly(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.OutputOnFailureOnly = true
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	message := func(text string, scopes ...string) {
+		r.RenderMessage(echelon.NewLogEntryMessage(scopes, echelon.InfoLevel, text))
+	}
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "ok"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "ok", "broken"))
+	message("hidden", "ok")
+	message("shown", "ok", "broken")
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "ok", "broken"))
+	assert.Contains(t, out.String(), "shown\n")
+	assert.Contains(t, out.String(), "'broken' failed in")
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "ok"))
+	assert.Contains(t, out.String(), "'ok' succeeded in")
+	assert.NotContains(t, out.String(), "hidden")
+	assert.NotContains(t, out.String(), "Started")
+}
+
+func Test_SimpleRenderer_Levels(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.FilterLevel = true
+	rendererConfig.Level = echelon.InfoLevel
+	rendererConfig.LevelBadges = true
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.DebugLevel, "hidden"))
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.InfoLevel, "plain"))
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.ErrorLevel, "broken"))
+	assert.Equal(t, "[INFO] plain\n\033[31m[ERROR] broken\033[0m\n", out.String())
+}
+
+func Test_SimpleRenderer_ZeroConfigRendersAllLevels(t *testing.T) {
+	var out bytes.Buffer
+	r := NewSimpleRendererWithConfig(&out, &config.SimpleRendererConfig{Colors: &terminal.ColorSchema{}})
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.InfoLevel, "info"))
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.TraceLevel, "trace"))
+	assert.Equal(t, "info\ntrace\n", out.String())
+}
+
+func Test_SimpleRenderer_CollapseRepeatedMessages(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.CollapseRepeatedMessages = config.CollapseIgnoringDigits
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	for i := 1; i <= 42; i++ {
+		r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.InfoLevel, "waiting for lock %d...", i))
+	}
+	r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.InfoLevel, "locked"))
+	assert.Equal(t, "waiting for lock 1...\nwaiting for lock 42... (x42)\nlocked\n", out.String())
+}
+
+func Test_SimpleRenderer_CollapseRepeatedMessages_RootFinished(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.CollapseRepeatedMessages = config.CollapseIgnoringDigits
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	for i := 1; i <= 3; i++ {
+		r.RenderMessage(echelon.NewLogEntryMessage(nil, echelon.InfoLevel, "waiting %d", i))
+		r.RenderMessage(echelon.NewLogEntryMessage([]string{"poll"}, echelon.InfoLevel, "polling %d", i))
+	}
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.Equal(t, "waiting 1\npolling 1\nwaiting 3 (x3)\npolling 3 (x3)\n", out.String())
+}
+
+func Test_SimpleRenderer_LogDirectory(t *testing.T) {
+	var out bytes.Buffer
+	path, err := ioutil.TempDir("", "echelon-run")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Colors.NeutralColor = -1
+	rendererConfig.FilterLevel = true
+	rendererConfig.Level = echelon.InfoLevel
+	rendererConfig.LogDirectory = path
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "test"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"test"}, echelon.DebugLevel, "details"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	logPath := filepath.Join(path, "test.log")
+	assert.Contains(t, out.String(), "'test' failed in")
+	assert.Contains(t, out.String(), "full log: "+logPath+"\n")
+	assert.NotContains(t, out.String(), "details")
+	content, err := ioutil.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "details\n", string(content))
+	assert.FileExists(t, filepath.Join(path, "index.json"))
+	assert.NoError(t, r.Err())
+}
+
+func Test_SimpleRenderer_LogDirectoryError(t *testing.T) {
+	file, err := ioutil.TempFile("", "echelon-run")
+	assert.NoError(t, err)
+	_ = file.Close()
+	defer os.Remove(file.Name())
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.LogDirectory = filepath.Join(file.Name(), "logs")
+	r := NewSimpleRendererWithConfig(&bytes.Buffer{}, rendererConfig)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.Error(t, r.Err())
+}
+
+func Test_SimpleRenderer_LogFileError(t *testing.T) {
+	var out bytes.Buffer
+	path, err := ioutil.TempDir("", "echelon-run")
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	assert.NoError(t, os.Mkdir(filepath.Join(path, "test.log"), 0o755))
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Colors.NeutralColor = -1
+	rendererConfig.LogDirectory = path
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	assert.Contains(t, out.String(), "'test' failed in")
+	assert.NotContains(t, out.String(), "full log:")
+	assert.Error(t, r.Err())
+}
+
+func Test_SimpleRenderer_FailureReport(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.Colors = &terminal.ColorSchema{SuccessColor: -1, FailureColor: -1, NeutralColor: -1}
+	rendererConfig.FailureReport = true
+	rendererConfig.FailureReportLines = 2
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build", "test"))
+	for _, line := range []string{"one", "two", "three"} {
+		r.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "test"}, echelon.InfoLevel, line))
+	}
+	r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("exit status 1"), "build", "test"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "lint"))
+	r.RenderScopeFinished(echelon.NewLogScopeSkipped("cached", "lint"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "build"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	output := withoutDurations(strings.ReplaceAll(out.String(), terminal.ResetSequence, ""))
+	assert.Contains(t, output, "'test' failed in <d>: exit status 1\n")
+	assert.Contains(t, output, "'lint' skipped: cached\n")
+	assert.True(t, strings.HasSuffix(output, "\nFailures:\n"+
+		"build failed in <d>\n"+
+		"build/test failed in <d>: exit status 1\n    two\n    three\n"+
+		"\n0 succeeded, 2 failed, 1 skipped in <d>\n"))
+}
+
+func Test_SimpleRenderer_RunSummaries(t *testing.T) {
+	var out bytes.Buffer
+	rendererConfig := config.NewDefaultSimpleRendererConfig()
+	rendererConfig.SlowestScopes = 1
+	rendererConfig.CriticalPath = true
+	r := NewSimpleRendererWithConfig(&out, rendererConfig)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "build", "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "build", "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "build"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.True(t, strings.HasSuffix(withoutDurations(out.String()), "\nSlowest scopes:\n  <d>  build/test\n"+
+		"\nCritical path:\n  <d>  build\n  <d>    test\n"))
+}
@@ -0,0 +1,153 @@
+package renderers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roberChen/echelon"
+	"github.com/roberChen/echelon/renderers/internal/timeline"
+)
+
+// tapIndent is the indentation of subtests
+const tapIndent = "    "
+
+// TAPRenderer is a renderer which writes the run in TAP version 13 once the root finishes. Every finished
+// scope becomes an "ok" or "not ok" line with its path, scopes with nested scopes are subtests whose
+// lines are indented, and failed scopes are followed by YAML diagnostics with their cause and messages.
+// Skipped scopes have the SKIP directive, and # in paths is escaped as \# so that it isn't taken for one.
+type TAPRenderer struct {
+	out      io.Writer
+	timeline *timeline.Recorder
+	// messages are the messages of scopes, the key is the path of scope
+	messages map[string][]string
+	// lock guards messages, err and the spans of timeline
+	lock sync.Mutex
+	err  error
+}
+
+// NewTAPRenderer creates a renderer writing TAP to out
+func NewTAPRenderer(out io.Writer) *TAPRenderer {
+	return &TAPRenderer{
+		out:      out,
+		timeline: timeline.NewRecorder(0),
+		messages: make(map[string][]string),
+	}
+}
+
+// RenderScopeStarted records the start of scope
+func (r *TAPRenderer) RenderScopeStarted(entry *echelon.LogScopeStarted) {
+	if len(entry.GetScopes()) > 0 {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.timeline.Start(entry.GetScopes())
+	}
+}
+
+// RenderScopeFinished records the end and outcome of scope, TAP is written once the root finishes
+func (r *TAPRenderer) RenderScopeFinished(entry *echelon.LogScopeFinished) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.timeline.Finish(entry)
+	if len(entry.GetScopes()) == 0 {
+		r.err = r.write()
+	}
+}
+
+// Err returns the error of writing TAP once the root has finished
+func (r *TAPRenderer) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// RenderMessage keeps message for the diagnostics of its scope
+func (r *TAPRenderer) RenderMessage(entry *echelon.LogEntryMessage) {
+	path := strings.Join(entry.GetScopes(), "/")
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages[path] = append(r.messages[path], strings.Split(entry.GetMessage(), "\n")...)
+}
+
+// RenderProcess does nothing, progress isn't part of TAP
+func (r *TAPRenderer) RenderProcess(entry *echelon.LogProcessMessage) {}
+
+// Write writes TAP of everything recorded so far to out, scopes which haven't finished are not ok. It's a
+// coroutine safe function.
+func (r *TAPRenderer) Write() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.write()
+}
+
+// write writes TAP to out, the lock must be held by caller
+func (r *TAPRenderer) write() error {
+	_, end := r.timeline.Bounds()
+	var output strings.Builder
+	output.WriteString("TAP version 13\n")
+	r.writeTests(&output, r.timeline.Root(), "", end)
+	_, err := io.WriteString(r.out, output.String())
+	return err
+}
+
+// writeTests writes the test lines of children of span with indent, followed by the plan
+func (r *TAPRenderer) writeTests(output *strings.Builder, span *timeline.Span, indent string, end time.Time) {
+	for i, child := range span.Children {
+		if len(child.Children) > 0 {
+			output.WriteString(fmt.Sprintf("%s%s# Subtest: %s\n", indent, tapIndent, child.Path()))
+			r.writeTests(output, child, indent+tapIndent, end)
+		}
+		status := "ok"
+		if child.Outcome == timeline.Failed || child.Outcome == timeline.Running {
+			status = "not ok"
+		}
+		line := fmt.Sprintf("%s%s %d - %s", indent, status, i+1, tapDescription(child.Path()))
+		if child.Outcome == timeline.Skipped {
+			line += " # SKIP"
+			if child.Cause != "" {
+				line += " " + child.Cause
+			}
+		}
+		output.WriteString(line + "\n")
+		if status != "ok" {
+			r.writeDiagnostics(output, child, indent+"  ", end)
+		}
+	}
+	output.WriteString(fmt.Sprintf("%s1..%d\n", indent, len(span.Children)))
+}
+
+// writeDiagnostics writes the YAML diagnostics of failed span with indent
+func (r *TAPRenderer) writeDiagnostics(output *strings.Builder, span *timeline.Span, indent string, end time.Time) {
+	message := span.Cause
+	if span.Outcome == timeline.Running {
+		message = unfinishedScopeMessage
+	}
+	output.WriteString(indent + "---\n")
+	if message != "" {
+		output.WriteString(fmt.Sprintf("%smessage: %s\n", indent, yamlString(message)))
+	}
+	output.WriteString(fmt.Sprintf("%sseverity: fail\n", indent))
+	output.WriteString(fmt.Sprintf("%sduration_ms: %d\n", indent, spanEnd(span, end).Sub(span.Start).Milliseconds()))
+	if messages := r.messages[span.Path()]; len(messages) > 0 {
+		output.WriteString(indent + "messages:\n")
+		for _, line := range messages {
+			output.WriteString(fmt.Sprintf("%s  - %s\n", indent, yamlString(line)))
+		}
+	}
+	output.WriteString(indent + "...\n")
+}
+
+// tapDescription returns path as the description of a test line, escaping backslashes and # so that
+// they can't start a directive
+func tapDescription(path string) string {
+	return strings.NewReplacer(`\`, `\\`, "#", `\#`).Replace(path)
+}
+
+// yamlString returns text as a double quoted YAML scalar, which is the same as a JSON string
+func yamlString(text string) string {
+	quoted, _ := json.Marshal(text)
+	return string(quoted)
+}
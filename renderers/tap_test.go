@@ -0,0 +1,80 @@
+//nolint:testpackage
+package renderers
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/roberChen/echelon"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TAPRenderer(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTAPRenderer(&out)
+	for _, scopes := range [][]string{{"build"}, {"build", "compile"}, {"build", "test"}, {"lint"}, {"deploy"}} {
+		r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, scopes...))
+	}
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "test"}, echelon.ErrorLevel, "expected \"1\"\ngot 2"))
+	r.RenderMessage(echelon.NewLogEntryMessage([]string{"build", "compile"}, echelon.InfoLevel, "compiled"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "build", "compile"))
+	r.RenderScopeFinished(echelon.NewLogScopeFailed(errors.New("exit status 1"), "build", "test"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false, "build"))
+	r.RenderScopeFinished(echelon.NewLogScopeSkipped("cached", "lint"))
+	for _, span := range r.timeline.Spans() {
+		span.End = span.Start
+	}
+	r.timeline.Root().End = r.timeline.Spans()[0].Start
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(false))
+	assert.Equal(t, `TAP version 13
+    # Subtest: build
+    ok 1 - build/compile
+    not ok 2 - build/test
+      ---
+      message: "exit status 1"
+      severity: fail
+      duration_ms: 0
+      messages:
+        - "expected \"1\""
+        - "got 2"
+      ...
+    1..2
+not ok 1 - build
+  ---
+  severity: fail
+  duration_ms: 0
+  ...
+ok 2 - lint # SKIP cached
+not ok 3 - deploy
+  ---
+  message: "scope did not finish"
+  severity: fail
+  duration_ms: 0
+  ...
+1..3
+`, out.String())
+}
+
+func Test_TAPRenderer_Err(t *testing.T) {
+	r := NewTAPRenderer(failingWriter{})
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "lint"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "lint"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.EqualError(t, r.Err(), "disk full")
+}
+
+func Test_TAPRenderer_EscapesDescriptions(t *testing.T) {
+	var out bytes.Buffer
+	r := NewTAPRenderer(&out)
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, "issue #12 TODO"))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true, "issue #12 TODO"))
+	r.RenderScopeStarted(echelon.NewLogScopeStarted(echelon.NoProgress, `C:\build`))
+	r.RenderScopeFinished(echelon.NewLogScopeSkipped("#3", `C:\build`))
+	r.RenderScopeFinished(echelon.NewLogScopeFinished(true))
+	assert.Equal(t, `TAP version 13
+ok 1 - issue \#12 TODO
+ok 2 - C:\\build # SKIP #3
+1..2
+`, out.String())
+}
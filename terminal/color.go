@@ -2,11 +2,14 @@ package terminal
 
 import "fmt"
 
-// ColorSchema contains success/failure/neutral color
+// ColorSchema contains success/failure/neutral color, and colors of log levels
 type ColorSchema struct {
 	SuccessColor int
 	FailureColor int
 	NeutralColor int
+	// LevelColors are colors of messages indexed by log level from error to trace, messages
+	// of levels out of range are uncolored
+	LevelColors []int
 }
 
 // ResetSequence reset ANSI sequence.
@@ -29,17 +32,30 @@ const (
 	CyanColor
 	// WhiteColor color
 	WhiteColor
+
+	// NoColor leaves text uncolored where a color is optional
+	NoColor = -1
 )
 
 // DefaultColorSchema will returns a color schema
 //
-// By default, success color is green, failure color is red, and neutral color is yellow
+// By default, success color is green, failure color is red, and neutral color is yellow.
+// Errors are red, warnings are yellow, debug messages are cyan, others are uncolored.
 func DefaultColorSchema() *ColorSchema {
 	return &ColorSchema{
 		SuccessColor: GreenColor,
 		FailureColor: RedColor,
 		NeutralColor: YellowColor,
+		LevelColors:  []int{RedColor, YellowColor, NoColor, CyanColor, NoColor},
+	}
+}
+
+// LevelColor returns color of messages of level, it's NoColor for levels without colors
+func (schema *ColorSchema) LevelColor(level uint32) int {
+	if int(level) >= len(schema.LevelColors) {
+		return NoColor
 	}
+	return schema.LevelColors[level]
 }
 
 // GetColoredText will colorize text with color
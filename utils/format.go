@@ -33,3 +33,41 @@ func FormatDuration(duration time.Duration, showDecimals bool) string {
 	hours := int(math.Floor(duration.Hours()))
 	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
 }
+
+// FormatBytes will format size of bytes with units
+//
+// If si is true, it uses SI units (kB, MB, ...) of 1000, else it uses IEC units (KiB, MiB, ...) of 1024.
+// Sizes less than one unit are printed as bytes, like "512 B", others are printed with one decimal, like "1.5 MiB".
+func FormatBytes(bytes int64, si bool) string {
+	base := int64(1024)
+	prefixes := "KMGTPE"
+	suffix := "iB"
+	if si {
+		base = 1000
+		prefixes = "kMGTPE"
+		suffix = "B"
+	}
+	if bytes < base && bytes > -base {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	exponent := 0
+	for math.Abs(value/float64(base)) >= float64(base) && exponent < len(prefixes)-1 {
+		value /= float64(base)
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %c%s", value/float64(base), prefixes[exponent], suffix)
+}
+
+// FormatCount will format count with thousands separators, like "1,234"
+func FormatCount(count int64) string {
+	digits := fmt.Sprintf("%d", count)
+	sign := ""
+	if count < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits
+}
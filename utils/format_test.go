@@ -0,0 +1,31 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roberChen/echelon/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FormatDuration(t *testing.T) {
+	assert.Equal(t, "1.5s", utils.FormatDuration(1500*time.Millisecond, true))
+	assert.Equal(t, "1s", utils.FormatDuration(1500*time.Millisecond, false))
+	assert.Equal(t, "02:05", utils.FormatDuration(125*time.Second, true))
+	assert.Equal(t, "01:00:01", utils.FormatDuration(time.Hour+time.Second, true))
+}
+
+func Test_FormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", utils.FormatBytes(512, false))
+	assert.Equal(t, "1.5 KiB", utils.FormatBytes(1536, false))
+	assert.Equal(t, "1.5 kB", utils.FormatBytes(1500, true))
+	assert.Equal(t, "2.0 MiB", utils.FormatBytes(2*1024*1024, false))
+	assert.Equal(t, "3.0 GB", utils.FormatBytes(3000000000, true))
+}
+
+func Test_FormatCount(t *testing.T) {
+	assert.Equal(t, "0", utils.FormatCount(0))
+	assert.Equal(t, "999", utils.FormatCount(999))
+	assert.Equal(t, "1,234", utils.FormatCount(1234))
+	assert.Equal(t, "-1,234,567", utils.FormatCount(-1234567))
+}
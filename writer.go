@@ -0,0 +1,82 @@
+package echelon
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxWriterLine is the length of pending text of writers of loggers which is sent as a message even
+// though its line isn't complete
+const maxWriterLine = 64 << 10
+
+// lineWriter is a io.WriteCloser which sends every line written to it as a message of logger
+type lineWriter struct {
+	lock    sync.Mutex
+	logger  *Logger
+	level   LogLevel
+	pending bytes.Buffer
+}
+
+// Writer returns a writer which sends every line written to it as a message with level of
+// logger, like output of a command. Lines are sent once they're complete, so a line written
+// in several chunks is still a single message; the last incomplete line is sent on Close.
+//
+// A line longer than 64KiB is sent in several messages so that output without new lines doesn't
+// pile up in memory. The end of a long line which might be the beginning of a secret is kept for
+// the next message, while matches of secret patterns are only masked within a message.
+func (logger *Logger) Writer(level LogLevel) io.WriteCloser {
+	return &lineWriter{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// Write sends all complete lines of p with pending text written before
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.pending.Write(p)
+	for {
+		end := bytes.IndexByte(w.pending.Bytes(), '\n')
+		if end < 0 {
+			w.sendLongLine()
+			return len(p), nil
+		}
+		line := string(w.pending.Next(end + 1))
+		w.logger.Logf(w.level, "%s", line[:end])
+	}
+}
+
+// sendLongLine sends the pending incomplete line once it's longer than maxWriterLine, secrets are masked
+// first and the last bytes which might be the beginning of a secret are kept pending. The lock must be
+// held by caller.
+func (w *lineWriter) sendLongLine() {
+	if w.pending.Len() <= maxWriterLine {
+		return
+	}
+	text := w.logger.redactor.redact(w.pending.String())
+	cut := len(text) - w.logger.redactor.overlap()
+	// don't split a character in two messages
+	for cut > 0 && cut < len(text) && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if cut <= 0 {
+		return
+	}
+	w.logger.Logf(w.level, "%s", text[:cut])
+	w.pending.Reset()
+	w.pending.WriteString(text[cut:])
+}
+
+// Close sends the pending incomplete line
+func (w *lineWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.pending.Len() > 0 {
+		w.logger.Logf(w.level, "%s", w.pending.String())
+		w.pending.Reset()
+	}
+	return nil
+}